@@ -0,0 +1,156 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: adapter/v1/metering.proto
+
+package adapterv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	MeteringService_CreateUsageEvent_FullMethodName      = "/adapter.v1.MeteringService/CreateUsageEvent"
+	MeteringService_BatchCreateUsageEvent_FullMethodName = "/adapter.v1.MeteringService/BatchCreateUsageEvent"
+)
+
+// MeteringServiceClient is the client API for MeteringService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MeteringService sends usage events to the Azure Marketplace metering API.
+type MeteringServiceClient interface {
+	// CreateUsageEvent sends a single usage event.
+	CreateUsageEvent(ctx context.Context, in *CreateUsageEventRequest, opts ...grpc.CallOption) (*CreateUsageEventResponse, error)
+	// BatchCreateUsageEvent sends a batch of usage events, the results keep the order of the events.
+	BatchCreateUsageEvent(ctx context.Context, in *BatchCreateUsageEventRequest, opts ...grpc.CallOption) (*BatchCreateUsageEventResponse, error)
+}
+
+type meteringServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMeteringServiceClient(cc grpc.ClientConnInterface) MeteringServiceClient {
+	return &meteringServiceClient{cc}
+}
+
+func (c *meteringServiceClient) CreateUsageEvent(ctx context.Context, in *CreateUsageEventRequest, opts ...grpc.CallOption) (*CreateUsageEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateUsageEventResponse)
+	err := c.cc.Invoke(ctx, MeteringService_CreateUsageEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meteringServiceClient) BatchCreateUsageEvent(ctx context.Context, in *BatchCreateUsageEventRequest, opts ...grpc.CallOption) (*BatchCreateUsageEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCreateUsageEventResponse)
+	err := c.cc.Invoke(ctx, MeteringService_BatchCreateUsageEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MeteringServiceServer is the server API for MeteringService service.
+// All implementations must embed UnimplementedMeteringServiceServer
+// for forward compatibility
+//
+// MeteringService sends usage events to the Azure Marketplace metering API.
+type MeteringServiceServer interface {
+	// CreateUsageEvent sends a single usage event.
+	CreateUsageEvent(context.Context, *CreateUsageEventRequest) (*CreateUsageEventResponse, error)
+	// BatchCreateUsageEvent sends a batch of usage events, the results keep the order of the events.
+	BatchCreateUsageEvent(context.Context, *BatchCreateUsageEventRequest) (*BatchCreateUsageEventResponse, error)
+	mustEmbedUnimplementedMeteringServiceServer()
+}
+
+// UnimplementedMeteringServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMeteringServiceServer struct {
+}
+
+func (UnimplementedMeteringServiceServer) CreateUsageEvent(context.Context, *CreateUsageEventRequest) (*CreateUsageEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUsageEvent not implemented")
+}
+func (UnimplementedMeteringServiceServer) BatchCreateUsageEvent(context.Context, *BatchCreateUsageEventRequest) (*BatchCreateUsageEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateUsageEvent not implemented")
+}
+func (UnimplementedMeteringServiceServer) mustEmbedUnimplementedMeteringServiceServer() {}
+
+// UnsafeMeteringServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MeteringServiceServer will
+// result in compilation errors.
+type UnsafeMeteringServiceServer interface {
+	mustEmbedUnimplementedMeteringServiceServer()
+}
+
+func RegisterMeteringServiceServer(s grpc.ServiceRegistrar, srv MeteringServiceServer) {
+	s.RegisterService(&MeteringService_ServiceDesc, srv)
+}
+
+func _MeteringService_CreateUsageEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUsageEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeteringServiceServer).CreateUsageEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeteringService_CreateUsageEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeteringServiceServer).CreateUsageEvent(ctx, req.(*CreateUsageEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeteringService_BatchCreateUsageEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateUsageEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeteringServiceServer).BatchCreateUsageEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeteringService_BatchCreateUsageEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeteringServiceServer).BatchCreateUsageEvent(ctx, req.(*BatchCreateUsageEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MeteringService_ServiceDesc is the grpc.ServiceDesc for MeteringService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MeteringService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "adapter.v1.MeteringService",
+	HandlerType: (*MeteringServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUsageEvent",
+			Handler:    _MeteringService_CreateUsageEvent_Handler,
+		},
+		{
+			MethodName: "BatchCreateUsageEvent",
+			Handler:    _MeteringService_BatchCreateUsageEvent_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "adapter/v1/metering.proto",
+}
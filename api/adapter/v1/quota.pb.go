@@ -0,0 +1,206 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: adapter/v1/quota.proto
+
+package adapterv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AvailableGPURequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *AvailableGPURequest) Reset() {
+	*x = AvailableGPURequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_adapter_v1_quota_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AvailableGPURequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailableGPURequest) ProtoMessage() {}
+
+func (x *AvailableGPURequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adapter_v1_quota_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailableGPURequest.ProtoReflect.Descriptor instead.
+func (*AvailableGPURequest) Descriptor() ([]byte, []int) {
+	return file_adapter_v1_quota_proto_rawDescGZIP(), []int{0}
+}
+
+type AvailableGPUResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Gpu int64 `protobuf:"varint,1,opt,name=gpu,proto3" json:"gpu,omitempty"`
+}
+
+func (x *AvailableGPUResponse) Reset() {
+	*x = AvailableGPUResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_adapter_v1_quota_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AvailableGPUResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailableGPUResponse) ProtoMessage() {}
+
+func (x *AvailableGPUResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adapter_v1_quota_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailableGPUResponse.ProtoReflect.Descriptor instead.
+func (*AvailableGPUResponse) Descriptor() ([]byte, []int) {
+	return file_adapter_v1_quota_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AvailableGPUResponse) GetGpu() int64 {
+	if x != nil {
+		return x.Gpu
+	}
+	return 0
+}
+
+var File_adapter_v1_quota_proto protoreflect.FileDescriptor
+
+var file_adapter_v1_quota_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x71, 0x75, 0x6f,
+	0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x22, 0x15, 0x0a, 0x13, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c,
+	0x65, 0x47, 0x50, 0x55, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x28, 0x0a, 0x14, 0x41,
+	0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x50, 0x55, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x67, 0x70, 0x75, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x03, 0x67, 0x70, 0x75, 0x32, 0x61, 0x0a, 0x0c, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x51, 0x0a, 0x0c, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62,
+	0x6c, 0x65, 0x47, 0x50, 0x55, 0x12, 0x1f, 0x2e, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x50, 0x55, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x50, 0x55,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x3b, 0x5a, 0x39, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x79, 0x64, 0x61, 0x74, 0x61, 0x61, 0x69, 0x2f, 0x61,
+	0x7a, 0x75, 0x72, 0x65, 0x2d, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x61, 0x64, 0x61, 0x70,
+	0x74, 0x65, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_adapter_v1_quota_proto_rawDescOnce sync.Once
+	file_adapter_v1_quota_proto_rawDescData = file_adapter_v1_quota_proto_rawDesc
+)
+
+func file_adapter_v1_quota_proto_rawDescGZIP() []byte {
+	file_adapter_v1_quota_proto_rawDescOnce.Do(func() {
+		file_adapter_v1_quota_proto_rawDescData = protoimpl.X.CompressGZIP(file_adapter_v1_quota_proto_rawDescData)
+	})
+	return file_adapter_v1_quota_proto_rawDescData
+}
+
+var file_adapter_v1_quota_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_adapter_v1_quota_proto_goTypes = []interface{}{
+	(*AvailableGPURequest)(nil),  // 0: adapter.v1.AvailableGPURequest
+	(*AvailableGPUResponse)(nil), // 1: adapter.v1.AvailableGPUResponse
+}
+var file_adapter_v1_quota_proto_depIdxs = []int32{
+	0, // 0: adapter.v1.QuotaService.AvailableGPU:input_type -> adapter.v1.AvailableGPURequest
+	1, // 1: adapter.v1.QuotaService.AvailableGPU:output_type -> adapter.v1.AvailableGPUResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_adapter_v1_quota_proto_init() }
+func file_adapter_v1_quota_proto_init() {
+	if File_adapter_v1_quota_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_adapter_v1_quota_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AvailableGPURequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_adapter_v1_quota_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AvailableGPUResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_adapter_v1_quota_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_adapter_v1_quota_proto_goTypes,
+		DependencyIndexes: file_adapter_v1_quota_proto_depIdxs,
+		MessageInfos:      file_adapter_v1_quota_proto_msgTypes,
+	}.Build()
+	File_adapter_v1_quota_proto = out.File
+	file_adapter_v1_quota_proto_rawDesc = nil
+	file_adapter_v1_quota_proto_goTypes = nil
+	file_adapter_v1_quota_proto_depIdxs = nil
+}
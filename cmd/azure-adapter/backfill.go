@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/backfill"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+// meteringBackfill validates the usage rows of a file, previews them and submits them in batches
+func meteringBackfill(args []string) int {
+	flags := newFlagSet("metering backfill")
+	path := flags.String("file", "", "CSV or NDJSON file of the usage rows (required)")
+	format := flags.String("format", "", "format of the file, csv or ndjson, from the extension by default")
+	tenantID := flags.String("tenant", "", "tenant of the registry, the default tenant when empty")
+	checkpointPath := flags.String("checkpoint", "", "checkpoint of the accepted rows, <file>.checkpoint by default")
+	batchSize := flags.Int("batch-size", backfill.MaxBatchSize, "events per batch")
+	maxAge := flags.Duration("max-age", 24*time.Hour, "oldest start time accepted by the marketplace")
+	preview := flags.Int("preview", 10, "rows shown before submitting")
+	dryRun := flags.Bool("dry-run", false, "validate and preview the rows without submitting them")
+	skipInvalid := flags.Bool("skip-invalid", false, "submit the valid rows when some are invalid")
+	yes := flags.Bool("yes", false, "submit without asking for confirmation")
+	timeout := flags.Duration("timeout", 10*time.Minute, "timeout of the whole backfill")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "the file is required")
+		flags.Usage()
+		return 2
+	}
+	if *checkpointPath == "" {
+		*checkpointPath = *path + ".checkpoint"
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fileFormat := backfill.Format(*format)
+	if fileFormat == "" {
+		if fileFormat, err = backfill.FormatFromPath(*path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	rows, err := backfill.Read(bytes.NewReader(data), fileFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	configuration, logger, err := setup.Load(setup.Features{Metering: true})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	checkpoint, err := backfill.LoadCheckpoint(*checkpointPath, backfill.Digest(data))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// the rows accepted by a previous run were valid then, they may be too old now
+	pending := []backfill.Row{}
+	for _, row := range rows {
+		if !checkpoint.Completed(row.Line) {
+			pending = append(pending, row)
+		}
+	}
+
+	valid, issues := backfill.Validate(pending, configuration.Metering, *maxAge, time.Now().UTC())
+	printPreview(valid, issues, len(rows)-len(pending), *preview)
+
+	if len(issues) > 0 && !*skipInvalid {
+		fmt.Fprintf(os.Stderr, "%d rows are invalid, fix them or use --skip-invalid\n", len(issues))
+		return 1
+	}
+	if *dryRun || len(valid) == 0 {
+		return 0
+	}
+	if !*yes && !confirm(fmt.Sprintf("submit %d events", len(valid))) {
+		fmt.Fprintln(os.Stderr, "aborted")
+		return 1
+	}
+
+	cred, err := setup.NewCredential(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	plans, err := setup.NewPlanHistory(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer plans.Close()
+
+	meteringClient, err := setup.NewMeteringRegistry(logger, configuration, cred, nil, plans, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := backfill.Submit(metering.WithTenant(ctx, *tenantID), meteringClient, valid, checkpoint, *batchSize,
+		func(progress backfill.Progress) {
+			fmt.Fprintf(os.Stderr, "submitted %d/%d events, %d accepted, %d failed\n",
+				progress.Submitted, progress.Total, progress.Accepted, progress.Failed)
+		})
+
+	// the sinks receive the events in background, so they drain before exiting
+	if closeErr := meteringClient.Close(ctx); closeErr != nil {
+		fmt.Fprintln(os.Stderr, closeErr)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v, run the command again to resume from checkpoint %s\n", err, *checkpointPath)
+		return 1
+	}
+
+	if code := printJSON(result); code != 0 || result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printPreview(valid []backfill.Row, issues []backfill.Issue, completed int, limit int) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "LINE\tDIMENSION\tQUANTITY\tSTART")
+	for i, row := range valid {
+		if i == limit {
+			fmt.Fprintf(writer, "...\t%d more\t\t\n", len(valid)-limit)
+			break
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%v\t%s\n",
+			row.Line, row.Event.DimensionID, row.Event.Quantity, row.Event.StartAt.UTC().Format(time.RFC3339))
+	}
+	writer.Flush()
+
+	for _, issue := range issues {
+		fmt.Printf("line %d is invalid: %s\n", issue.Line, issue.Reason)
+	}
+
+	fmt.Printf("%d rows to submit, %d invalid, %d accepted by a previous run\n", len(valid), len(issues), completed)
+}
+
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s? [y/N] ", question)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
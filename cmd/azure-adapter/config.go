@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+// configCheck loads the configuration of the features and builds the credential, without calling Azure
+func configCheck(args []string) int {
+	flags := newFlagSet("config check")
+	meteringFeature := flags.Bool("metering", false, "check the metering configuration")
+	quotaFeature := flags.Bool("quota", false, "check the quota configuration")
+	fulfillmentFeature := flags.Bool("fulfillment", false, "check the SaaS fulfillment configuration")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	features := setup.Features{
+		Server:      true,
+		Metering:    *meteringFeature,
+		Quota:       *quotaFeature,
+		Fulfillment: *fulfillmentFeature,
+	}
+	if features == (setup.Features{Server: true}) {
+		features.Metering, features.Quota, features.Fulfillment = true, true, true
+	}
+
+	configuration, _, err := setup.Load(features)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if _, err := setup.NewCredential(configuration); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid credential. Err: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("configuration is valid (metering: %v, quota: %v, fulfillment: %v, tenants: %d)\n",
+		features.Metering, features.Quota, features.Fulfillment, len(configuration.Tenant.Tenants))
+	return 0
+}
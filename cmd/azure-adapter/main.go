@@ -0,0 +1,65 @@
+// Package main for the azure-adapter executable, which runs the features of the adapter as subcommands
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: azure-adapter <command> [flags]
+
+Commands:
+  serve [--metering] [--quota] [--fulfillment]
+                                 runs the servers with the controllers of the features, all of them by default
+  quota get                      prints the GPUs available in the configured location
+  metering send                  sends an usage event to the marketplace metering API
+  metering backfill              submits the usage rows of a CSV or NDJSON file, resuming from a checkpoint
+  config check                   loads the configuration of the features and reports the errors
+
+The configuration is read from the env vars and the file of CONFIG_FILE, as in the serve command.
+`
+
+// command runs a subcommand with its arguments and returns the exit code
+type command func(args []string) int
+
+func main() {
+	commands := map[string]command{
+		"serve":             serve,
+		"quota get":         quotaGet,
+		"metering send":     meteringSend,
+		"metering backfill": meteringBackfill,
+		"config check":      configCheck,
+	}
+
+	args := os.Args[1:]
+	for _, words := range []int{2, 1} {
+		if len(args) < words {
+			continue
+		}
+		if run, ok := commands[strings.Join(args[:words], " ")]; ok {
+			os.Exit(run(args[words:]))
+		}
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// newFlagSet returns the flags of a subcommand, which print the usage of the subcommand on error
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("azure-adapter "+name, flag.ContinueOnError)
+}
+
+// printJSON writes the value to the standard output, indented
+func printJSON(value any) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
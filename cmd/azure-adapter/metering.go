@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+// meteringSend sends an usage event through the registry, like the metering endpoints do
+func meteringSend(args []string) int {
+	flags := newFlagSet("metering send")
+	dimension := flags.String("dimension", "", "dimension of the usage event (required)")
+	quantity := flags.Float64("quantity", 0, "quantity of the usage event (required)")
+	startAt := flags.String("start-at", "", "start time of the usage event in RFC 3339, the current hour by default")
+	tenantID := flags.String("tenant", "", "tenant of the registry, the default tenant when empty")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the request and of the sinks to drain")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dimension == "" || *quantity <= 0 {
+		fmt.Fprintln(os.Stderr, "the dimension and a positive quantity are required")
+		flags.Usage()
+		return 2
+	}
+
+	event := coreMetering.UsageEvent{
+		DimensionID: *dimension,
+		Quantity:    float32(*quantity),
+		StartAt:     time.Now().UTC().Truncate(time.Hour),
+	}
+	if *startAt != "" {
+		t, err := time.Parse(time.RFC3339, *startAt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid start time '%s'. Err: %v\n", *startAt, err)
+			return 2
+		}
+		event.StartAt = t
+	}
+
+	configuration, logger, err := setup.Load(setup.Features{Metering: true})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cred, err := setup.NewCredential(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	plans, err := setup.NewPlanHistory(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer plans.Close()
+
+	meteringClient, err := setup.NewMeteringRegistry(logger, configuration, cred, nil, plans, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	response, err := meteringClient.CreateUsageEvent(metering.WithTenant(ctx, *tenantID), event)
+
+	// the sinks receive the event in background, so they drain before exiting
+	if closeErr := meteringClient.Close(ctx); closeErr != nil {
+		fmt.Fprintln(os.Stderr, closeErr)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return printJSON(response)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+// quotaGet prints the GPUs available in the location of the configuration, or of the flags
+func quotaGet(args []string) int {
+	flags := newFlagSet("quota get")
+	location := flags.String("location", "", "location of the quota, instead of LOCATION")
+	machineType := flags.String("machine-type", "", "machine type of the quota, instead of MACHINE_TYPE")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the request")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// the flags take precedence over the env vars and the configuration file
+	for key, value := range map[string]string{"LOCATION": *location, "MACHINE_TYPE": *machineType} {
+		if value != "" {
+			os.Setenv(key, value)
+		}
+	}
+
+	configuration, logger, err := setup.Load(setup.Features{Quota: true})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cred, err := setup.NewCredential(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	restService, err := setup.NewQuotaService(logger, configuration, cred)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	gpu, err := restService.AvailableGPU(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return printJSON(map[string]any{
+		"location":    configuration.RESTService.Location,
+		"machineType": configuration.RESTService.MachineType,
+		"gpu":         gpu,
+	})
+}
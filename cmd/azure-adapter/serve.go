@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+// serve runs the servers with the controllers of the features until the process is signaled
+func serve(args []string) int {
+	flags := newFlagSet("serve")
+	meteringFeature := flags.Bool("metering", false, "serve the metering controllers")
+	quotaFeature := flags.Bool("quota", false, "serve the quota controllers")
+	fulfillmentFeature := flags.Bool("fulfillment", false, "serve the SaaS fulfillment controllers")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	features := setup.Features{
+		Server:      true,
+		Metering:    *meteringFeature,
+		Quota:       *quotaFeature,
+		Fulfillment: *fulfillmentFeature,
+	}
+	if features == (setup.Features{Server: true}) {
+		features.Metering, features.Quota, features.Fulfillment = true, true, true
+	}
+
+	configuration, logger, err := setup.Load(features)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return setup.Serve(context.Background(), logger, configuration)
+}
@@ -0,0 +1,172 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// Scopes required by the adapter routes
+const (
+	ScopeMeteringWrite    = "metering:write"
+	ScopeMeteringRead     = "metering:read"
+	ScopeQuotaRead        = "quota:read"
+	ScopeFulfillmentRead  = "fulfillment:read"
+	ScopeFulfillmentWrite = "fulfillment:write"
+)
+
+// PrincipalKey is the key of the authenticated Principal in the request context
+const PrincipalKey = "principal"
+
+// ErrNoCredentials is returned by an authenticator when the request doesn't carry its kind of credentials
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal represents an authenticated caller
+type Principal struct {
+	Subject string
+	Method  Method
+	Scopes  []string
+}
+
+// HasScopes returns true when the principal was granted all the scopes
+func (p Principal) HasScopes(scopes ...string) bool {
+	granted := map[string]bool{}
+	for _, scope := range p.Scopes {
+		granted[scope] = true
+	}
+
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// Authenticator defines an interface to verify the credentials of a request
+type Authenticator interface {
+	// Authenticate returns the principal of the request, or ErrNoCredentials when the request doesn't have
+	// credentials for this authenticator.
+	Authenticate(*http.Request) (Principal, error)
+}
+
+// NewAuthenticators initializes the authenticators of the enabled methods
+func NewAuthenticators(configuration Configuration) []Authenticator {
+	authenticators := []Authenticator{}
+
+	if configuration.Enabled(MethodHMAC) {
+		authenticators = append(authenticators, NewHMACAuthenticator(
+			configuration.HMACKeys, configuration.HMACScopes, configuration.HMACMaxSkew))
+	}
+
+	if configuration.Enabled(MethodBearer) {
+		authenticators = append(authenticators, NewBearerAuthenticator(configuration.BearerTokens))
+	}
+
+	if configuration.Enabled(MethodJWT) {
+		authenticators = append(authenticators, NewJWTAuthenticator(configuration))
+	}
+
+	return authenticators
+}
+
+// Guard protects the routes with the authenticators
+type Guard struct {
+	logger         logging.Logger
+	authenticators []Authenticator
+	validation     gin.HandlerFunc
+}
+
+// NewGuard initializes a guard, without authenticators every request is allowed
+func NewGuard(logger logging.Logger, authenticators []Authenticator) Guard {
+	return Guard{
+		logger:         logger,
+		authenticators: authenticators,
+	}
+}
+
+// WithValidation returns a copy of the guard that validates the requests once they are authenticated,
+// so unauthenticated callers don't learn the schema of the routes
+func (g Guard) WithValidation(validation gin.HandlerFunc) Guard {
+	g.validation = validation
+	return g
+}
+
+// Validate returns the validation middleware of the guard, for the routes that authenticate the requests
+// on their own
+func (g Guard) Validate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		g.next(ctx)
+	}
+}
+
+// Require returns a middleware that authenticates the request and checks it was granted the scopes
+func (g Guard) Require(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(g.authenticators) == 0 {
+			g.next(ctx)
+			return
+		}
+
+		principal, status, err := g.authenticate(ctx.Request, ctx.Request.Method+" "+ctx.FullPath(), scopes)
+		if err != nil {
+			if status == http.StatusUnauthorized {
+				ctx.Header("WWW-Authenticate", "Bearer")
+			}
+			ctx.AbortWithStatusJSON(status, gin.H{"message": err.Error()})
+			return
+		}
+
+		ctx.Set(PrincipalKey, principal)
+		g.next(ctx)
+	}
+}
+
+// next validates the request, when the guard has a validation, and calls the remaining handlers
+func (g Guard) next(ctx *gin.Context) {
+	if g.validation == nil {
+		ctx.Next()
+		return
+	}
+	g.validation(ctx)
+}
+
+// authenticate returns the principal of the request, or the status and the error of the response
+// when it isn't authenticated or wasn't granted the scopes
+func (g Guard) authenticate(req *http.Request, operation string, scopes []string) (Principal, int, error) {
+	for _, authenticator := range g.authenticators {
+		principal, err := authenticator.Authenticate(req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		if err != nil {
+			correlation.Logger(req.Context(), g.logger).Warnf("authentication of %s failed. Err: %v", operation, err)
+			return Principal{}, http.StatusUnauthorized, err
+		}
+
+		if !principal.HasScopes(scopes...) {
+			correlation.Logger(req.Context(), g.logger).Warnf("%s '%s' is missing scopes %v to %s", principal.Method, principal.Subject, scopes, operation)
+			return Principal{}, http.StatusForbidden, errors.New("missing required scopes " + strings.Join(scopes, " "))
+		}
+
+		return principal, http.StatusOK, nil
+	}
+
+	return Principal{}, http.StatusUnauthorized, errors.New("missing or invalid credentials")
+}
+
+// bearerToken returns the token of the Authorization header
+func bearerToken(req *http.Request) (string, bool) {
+	header := req.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
@@ -0,0 +1,251 @@
+package auth_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+)
+
+func TestGuard(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	configuration := auth.Configuration{
+		Methods:      []auth.Method{auth.MethodBearer, auth.MethodHMAC, auth.MethodJWT},
+		BearerTokens: map[string]string{"static-token": "metering:write"},
+		HMACKeys:     map[string]string{"partner": "secret"},
+		HMACScopes:   map[string]string{"partner": "metering:write quota:read"},
+		HMACMaxSkew:  time.Minute,
+		JWKSURL:      jwks.URL,
+		JWKSRefresh:  time.Hour,
+		Issuer:       "https://sts.windows.net/tenant/",
+		Audience:     "api://azure-adapter",
+		Leeway:       time.Minute,
+		HTTPTimeout:  time.Second,
+
+		AllowedApplications: []string{"client-application"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	guard := auth.NewGuard(logger, auth.NewAuthenticators(configuration))
+	router.POST("/metering/usageEvent", guard.Require(auth.ScopeMeteringWrite), func(ctx *gin.Context) {
+		body := bytes.Buffer{}
+		body.ReadFrom(ctx.Request.Body)
+		ctx.String(http.StatusOK, body.String())
+	})
+	router.GET("/available/gpu", guard.Require(auth.ScopeQuotaRead), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	token := func(kid string, claims jwt.MapClaims) string {
+		t.Helper()
+
+		jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		jwtToken.Header["kid"] = kid
+		signed, err := jwtToken.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	claims := func(scopes string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": configuration.Issuer,
+			"aud": configuration.Audience,
+			"sub": "subject",
+			"oid": "object-id",
+			"azp": "client-application",
+			"scp": scopes,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	body := `{"dimensionId":"gpu"}`
+	signature := auth.Sign([]byte("secret"), http.MethodPost, "/metering/usageEvent", timestamp, []byte(body))
+
+	hmacHeaders := map[string]string{
+		auth.HMACKeyIDHeader:     "partner",
+		auth.HMACTimestampHeader: timestamp,
+		auth.HMACSignatureHeader: signature,
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "missing credentials",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "static bearer token",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			headers:  map[string]string{"Authorization": "Bearer static-token"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "unknown bearer token",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			headers:  map[string]string{"Authorization": "Bearer other-token"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "static bearer token without scope",
+			method:   http.MethodGet,
+			path:     "/available/gpu",
+			headers:  map[string]string{"Authorization": "Bearer static-token"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "signed request",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			body:     body,
+			headers:  hmacHeaders,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "replayed signed request",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			body:     body,
+			headers:  hmacHeaders,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "tampered signed request",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			body:     `{"dimensionId":"cpu"}`,
+			headers:  hmacHeaders,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "jwt",
+			method:   http.MethodGet,
+			path:     "/available/gpu",
+			headers:  map[string]string{"Authorization": "Bearer " + token("key-1", claims("quota:read"))},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "jwt without scope",
+			method:   http.MethodPost,
+			path:     "/metering/usageEvent",
+			headers:  map[string]string{"Authorization": "Bearer " + token("key-1", claims("quota:read"))},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:   "jwt with another audience",
+			method: http.MethodGet,
+			path:   "/available/gpu",
+			headers: map[string]string{"Authorization": "Bearer " + token("key-1", jwt.MapClaims{
+				"iss": configuration.Issuer, "aud": "api://other", "sub": "subject",
+				"scp": "quota:read", "exp": time.Now().Add(time.Hour).Unix(),
+			})},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:   "jwt of another application",
+			method: http.MethodGet,
+			path:   "/available/gpu",
+			headers: map[string]string{"Authorization": "Bearer " + token("key-1", jwt.MapClaims{
+				"iss": configuration.Issuer, "aud": configuration.Audience, "sub": "subject", "appid": "other-application",
+				"scp": "quota:read", "exp": time.Now().Add(time.Hour).Unix(),
+			})},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "jwt signed by unknown key",
+			method:   http.MethodGet,
+			path:     "/available/gpu",
+			headers:  map[string]string{"Authorization": "Bearer " + token("key-2", claims("quota:read"))},
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expected {
+				t.Fatalf("expected status %d, got %d with %s", tt.expected, recorder.Code, recorder.Body.String())
+			}
+
+			if tt.expected == http.StatusOK && recorder.Body.String() != tt.body {
+				t.Fatalf("handler should read the request body, got %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGuardWithoutAuthenticators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	guard := auth.NewGuard(nil, nil)
+	router.GET("/available/gpu", guard.Require(auth.ScopeQuotaRead), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/available/gpu", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("should allow every request, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,52 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+type bearerAuthenticator struct {
+	tokens map[string][]string
+}
+
+// NewBearerAuthenticator initializes an authenticator of static bearer tokens, mapped to their space separated scopes
+func NewBearerAuthenticator(tokens map[string]string) Authenticator {
+	scopes := map[string][]string{}
+	for token, tokenScopes := range tokens {
+		scopes[token] = strings.Fields(tokenScopes)
+	}
+
+	return bearerAuthenticator{tokens: scopes}
+}
+
+// Authenticate compares the bearer token with every configured token in constant time
+func (a bearerAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	token, ok := bearerToken(req)
+	if !ok {
+		return Principal{}, ErrNoCredentials
+	}
+
+	var principal *Principal
+	for candidate, scopes := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			principal = &Principal{Subject: fingerprint(token), Method: MethodBearer, Scopes: scopes}
+		}
+	}
+
+	if principal == nil {
+		// it may be a token of other authenticator, e.g. a JWT
+		return Principal{}, ErrNoCredentials
+	}
+
+	return *principal, nil
+}
+
+// fingerprint identifies a secret in the logs without disclosing it
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
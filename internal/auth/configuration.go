@@ -0,0 +1,103 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Method defines an authentication method
+type Method string
+
+// Supported authentication methods
+const (
+	MethodBearer Method = "bearer"
+	MethodHMAC   Method = "hmac"
+	MethodJWT    Method = "jwt"
+)
+
+// Configuration defines the configuration of the inbound authentication.
+// Authentication is disabled when no method is enabled.
+type Configuration struct {
+	Methods []Method `envconfig:"AUTH_METHODS" default:""`
+
+	// BearerTokens maps each static token to its space separated scopes, e.g. token=metering:write quota:read
+	BearerTokens ScopeMap `envconfig:"AUTH_BEARER_TOKENS" default:""`
+
+	// HMACKeys maps each key id to its secret
+	HMACKeys map[string]string `envconfig:"AUTH_HMAC_KEYS" default:""`
+	// HMACScopes maps each key id to its space separated scopes, e.g. partner=metering:write;other=quota:read
+	HMACScopes  ScopeMap      `envconfig:"AUTH_HMAC_SCOPES" default:""`
+	HMACMaxSkew time.Duration `envconfig:"AUTH_HMAC_MAX_SKEW" default:"5m"`
+
+	JWKSURL         string        `envconfig:"AUTH_JWT_JWKS_URL" default:""`
+	JWKSRefresh     time.Duration `envconfig:"AUTH_JWT_JWKS_REFRESH" default:"1h"`
+	Issuer          string        `envconfig:"AUTH_JWT_ISSUER" default:""`
+	Audience        string        `envconfig:"AUTH_JWT_AUDIENCE" default:""`
+	Leeway          time.Duration `envconfig:"AUTH_JWT_LEEWAY" default:"1m"`
+	HTTPTimeout     time.Duration `envconfig:"AUTH_JWT_HTTP_TIMEOUT" default:"10s"`
+	AllowedSubjects []string      `envconfig:"AUTH_JWT_ALLOWED_SUBJECTS" default:""`
+	// AllowedApplications are the ids of the client applications (azp or appid) allowed to call, any when empty
+	AllowedApplications []string `envconfig:"AUTH_JWT_ALLOWED_APPLICATIONS" default:""`
+}
+
+// ScopeMap maps the credentials to their space separated scopes. The scopes have a ":", which the maps of envconfig
+// don't allow, so the env var separates the entries with ";" and each credential from its scopes with its last "=",
+// e.g. a=metering:write quota:read;b=quota:read. The entries without "=" are separated by the first ":".
+type ScopeMap map[string]string
+
+// Decode parses the entries of the env var
+func (m *ScopeMap) Decode(value string) error {
+	scopes := ScopeMap{}
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(entry, "=")
+		if separator < 0 {
+			separator = strings.Index(entry, ":")
+		}
+		if separator <= 0 {
+			return fmt.Errorf("invalid scopes entry %q, it must be credential=scopes", entry)
+		}
+		scopes[strings.TrimSpace(entry[:separator])] = strings.TrimSpace(entry[separator+1:])
+	}
+
+	*m = scopes
+	return nil
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	for _, method := range c.Methods {
+		switch method {
+		case MethodBearer, MethodHMAC:
+		case MethodJWT:
+			if c.JWKSURL == "" || c.Audience == "" {
+				return fmt.Errorf("jwt authentication requires AUTH_JWT_JWKS_URL and AUTH_JWT_AUDIENCE")
+			}
+		default:
+			return fmt.Errorf("invalid authentication method '%s'", method)
+		}
+	}
+
+	return nil
+}
+
+// Enabled returns true when the method is enabled
+func (c Configuration) Enabled(method Method) bool {
+	for _, m := range c.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+)
+
+func TestConfigurationLoadFromEnvVars(t *testing.T) {
+	t.Setenv("AUTH_METHODS", "bearer,hmac")
+	t.Setenv("AUTH_BEARER_TOKENS", "token=metering:write quota:read;padded==quota:read")
+	t.Setenv("AUTH_HMAC_KEYS", "partner:secret")
+	t.Setenv("AUTH_HMAC_SCOPES", "partner:metering:write")
+
+	configuration := auth.Configuration{}
+	if err := configuration.LoadFromEnvVars(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	expectedTokens := auth.ScopeMap{"token": "metering:write quota:read", "padded=": "quota:read"}
+	if diff := cmp.Diff(expectedTokens, configuration.BearerTokens); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	expectedScopes := auth.ScopeMap{"partner": "metering:write"}
+	if diff := cmp.Diff(expectedScopes, configuration.HMACScopes); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Setenv("AUTH_BEARER_TOKENS", "=metering:write")
+	if err := configuration.LoadFromEnvVars(); err == nil {
+		t.Fatal("expected an entry without a token to fail")
+	}
+}
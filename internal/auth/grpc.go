@@ -0,0 +1,57 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// UnaryServerInterceptor returns an interceptor that authenticates the gRPC requests and checks they were granted
+// the scopes of their full method, refusing the methods without scopes.
+// The metadata are authenticated as the headers of a POST to the full method. The HMAC signatures are refused,
+// they can't cover the messages, which are decoded before the interceptors, so a signature would authorize any message.
+func (g Guard) UnaryServerInterceptor(scopes map[string][]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if len(g.authenticators) == 0 {
+			return handler(ctx, req)
+		}
+
+		methodScopes, ok := scopes[info.FullMethod]
+		if !ok {
+			correlation.Logger(ctx, g.logger).Warnf("%s has no scopes, it is refused", info.FullMethod)
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, info.FullMethod, http.NoBody)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		for key, values := range md {
+			for _, value := range values {
+				httpReq.Header.Add(key, value)
+			}
+		}
+
+		if httpReq.Header.Get(HMACKeyIDHeader) != "" || httpReq.Header.Get(HMACSignatureHeader) != "" {
+			return nil, status.Error(codes.Unauthenticated, "hmac signatures aren't supported over gRPC")
+		}
+
+		if _, code, err := g.authenticate(httpReq, info.FullMethod, methodScopes); err != nil {
+			if code == http.StatusForbidden {
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,133 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of the HMAC signed requests
+const (
+	HMACKeyIDHeader     = "X-Adapter-Key-Id"
+	HMACTimestampHeader = "X-Adapter-Timestamp"
+	HMACSignatureHeader = "X-Adapter-Signature"
+)
+
+type hmacAuthenticator struct {
+	keys    map[string][]byte
+	scopes  map[string][]string
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewHMACAuthenticator initializes an authenticator of HMAC-SHA256 signed requests.
+// The signature is the hex encoded HMAC of SignatureBase and is accepted once, when the timestamp,
+// in unix seconds, is within the max skew of the current time.
+func NewHMACAuthenticator(keys map[string]string, scopes map[string]string, maxSkew time.Duration) Authenticator {
+	a := &hmacAuthenticator{
+		keys:    map[string][]byte{},
+		scopes:  map[string][]string{},
+		maxSkew: maxSkew,
+		now:     time.Now,
+		seen:    map[string]time.Time{},
+	}
+
+	for keyID, secret := range keys {
+		a.keys[keyID] = []byte(secret)
+		a.scopes[keyID] = strings.Fields(scopes[keyID])
+	}
+
+	return a
+}
+
+// SignatureBase returns the content signed by the HMAC requests, the method, the request URI, the timestamp
+// and the hex encoded SHA256 of the body separated by new lines
+func SignatureBase(method, requestURI, timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{method, requestURI, timestamp, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the request content
+func Sign(secret []byte, method, requestURI, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(SignatureBase(method, requestURI, timestamp, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Authenticate verifies the signature and timestamp of the request, restoring the body for the handlers
+func (a *hmacAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	keyID := req.Header.Get(HMACKeyIDHeader)
+	signature := req.Header.Get(HMACSignatureHeader)
+	timestamp := req.Header.Get(HMACTimestampHeader)
+	if keyID == "" && signature == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	secret, ok := a.keys[keyID]
+	if !ok {
+		return Principal{}, fmt.Errorf("unknown key id '%s'", keyID)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid %s header", HMACTimestampHeader)
+	}
+
+	now := a.now()
+	signedAt := time.Unix(seconds, 0)
+	if skew := now.Sub(signedAt); skew > a.maxSkew || skew < -a.maxSkew {
+		return Principal{}, errors.New("request timestamp is outside the allowed window")
+	}
+
+	body := []byte{}
+	if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return Principal{}, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := Sign(secret, req.Method, req.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return Principal{}, errors.New("invalid request signature")
+	}
+
+	if a.replayed(expected, signedAt, now) {
+		return Principal{}, errors.New("request signature was already used")
+	}
+
+	return Principal{Subject: keyID, Method: MethodHMAC, Scopes: a.scopes[keyID]}, nil
+}
+
+// replayed records the signature until it leaves the allowed window, returning true when it was already seen
+func (a *hmacAuthenticator) replayed(signature string, signedAt, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for seen, at := range a.seen {
+		if now.Sub(at) > a.maxSkew {
+			delete(a.seen, seen)
+		}
+	}
+
+	if _, ok := a.seen[signature]; ok {
+		return true
+	}
+	a.seen[signature] = signedAt
+
+	return false
+}
@@ -0,0 +1,215 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+)
+
+// minJWKSRefreshInterval limits the refreshes triggered by tokens signed with unknown keys
+const minJWKSRefreshInterval = time.Minute
+
+// jsonWebKey represents a public key of a JSON Web Key Set
+type jsonWebKey struct {
+	KeyID string `json:"kid"`
+	Type  string `json:"kty"`
+	Use   string `json:"use"`
+	N     string `json:"n"`
+	E     string `json:"e"`
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// keySet caches the keys of a JSON Web Key Set, refreshing them periodically and when a key is unknown.
+// A single refresh runs at a time, without the lock, and the cached keys are served while it runs.
+type keySet struct {
+	url     string
+	refresh time.Duration
+	timeout time.Duration
+	pl      coreHTTP.Pipeline
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	refreshedAt time.Time
+	// refreshing is closed when the refresh in progress completes, nil when there is none
+	refreshing chan struct{}
+	// err is the error of the last refresh
+	err error
+}
+
+func newKeySet(url string, refresh, timeout time.Duration) *keySet {
+	return &keySet{
+		url:     url,
+		refresh: refresh,
+		timeout: timeout,
+		pl:      coreHTTP.NewPipeline(),
+		keys:    map[string]interface{}{},
+	}
+}
+
+// key returns the public key with the id, refreshing the key set when it is stale or doesn't have the key.
+// The cached key is returned without waiting for the refresh, which keeps it while the key set is unavailable.
+func (s *keySet) key(ctx context.Context, keyID string) (interface{}, error) {
+	s.mu.Lock()
+	key, ok := s.keys[keyID]
+	stale := time.Since(s.refreshedAt) > s.refresh
+	if ok && !stale {
+		s.mu.Unlock()
+		return key, nil
+	}
+
+	if !stale && time.Since(s.refreshedAt) <= minJWKSRefreshInterval {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("unknown signing key '%s'", keyID)
+	}
+
+	refreshing := s.refreshing
+	if refreshing == nil {
+		refreshing = make(chan struct{})
+		s.refreshing = refreshing
+		go s.refreshKeys(refreshing)
+	}
+	s.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	select {
+	case <-refreshing:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok = s.keys[keyID]; ok {
+		return key, nil
+	}
+	if s.err != nil {
+		return nil, fmt.Errorf("could not fetch the JWKS. Err: %v", s.err)
+	}
+	return nil, fmt.Errorf("unknown signing key '%s'", keyID)
+}
+
+// refreshKeys fetches the key set, without the context of the request that triggered it since every request
+// waiting for the keys shares it, and swaps the keys when it succeeds
+func (s *keySet) refreshKeys(refreshing chan struct{}) {
+	keys, err := s.fetch(context.Background())
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+		s.refreshedAt = time.Now()
+	}
+	s.err = err
+	s.refreshing = nil
+	s.mu.Unlock()
+
+	close(refreshing)
+}
+
+func (s *keySet) fetch(ctx context.Context) (map[string]interface{}, error) {
+	tCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := coreHTTP.NewRequest(tCtx, http.MethodGet, s.url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.pl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !resp.HasStatusCode(http.StatusOK) {
+		return nil, fmt.Errorf("request failed with error %s", resp.Status)
+	}
+
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s'. Err: %v", jwk.KeyID, err)
+		}
+		if key != nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey decodes RSA and EC keys, returning nil for the unsupported key types
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Type {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
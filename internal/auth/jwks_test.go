@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeySetServesCachedKeysWhileRefreshing(t *testing.T) {
+	release := make(chan struct{})
+	requests := atomic.Int32{}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(big.NewInt(65537).Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(3).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	keys := newKeySet(jwks.URL, time.Nanosecond, 10*time.Second)
+
+	if _, err := keys.key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	// the key set is stale, the refresh blocks on the endpoint
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := keys.key(context.Background(), "key-1"); err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the cached key without waiting for the refresh, waited %v", elapsed)
+	}
+
+	// an unknown key waits for the refresh in progress, until the request is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := keys.key(ctx, "key-2"); err != context.DeadlineExceeded {
+		t.Fatalf("expected the request to be cancelled, got %v", err)
+	}
+
+	if count := requests.Load(); count != 2 {
+		t.Fatalf("expected a single refresh in progress, got %d requests", count)
+	}
+}
@@ -0,0 +1,117 @@
+// Package auth provides inbound authentication for the adapter endpoints
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type jwtAuthenticator struct {
+	keys                *keySet
+	parser              *jwt.Parser
+	allowedSubjects     map[string]bool
+	allowedApplications map[string]bool
+}
+
+// NewJWTAuthenticator initializes an authenticator of Azure AD access tokens, signed by the keys of the JWKS URL.
+// The scopes of the principal are the delegated scopes (scp) and the application roles (roles) of the token.
+func NewJWTAuthenticator(configuration Configuration) Authenticator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithAudience(configuration.Audience),
+		jwt.WithLeeway(configuration.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if configuration.Issuer != "" {
+		options = append(options, jwt.WithIssuer(configuration.Issuer))
+	}
+
+	allowedSubjects := map[string]bool{}
+	for _, subject := range configuration.AllowedSubjects {
+		allowedSubjects[subject] = true
+	}
+
+	allowedApplications := map[string]bool{}
+	for _, application := range configuration.AllowedApplications {
+		allowedApplications[application] = true
+	}
+
+	return jwtAuthenticator{
+		keys:                newKeySet(configuration.JWKSURL, configuration.JWKSRefresh, configuration.HTTPTimeout),
+		parser:              jwt.NewParser(options...),
+		allowedSubjects:     allowedSubjects,
+		allowedApplications: allowedApplications,
+	}
+}
+
+// Authenticate validates the signature and the claims of the bearer token
+func (a jwtAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	token, ok := bearerToken(req)
+	if !ok || strings.Count(token, ".") != 2 {
+		return Principal{}, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		keyID, _ := t.Header["kid"].(string)
+		return a.keys.key(req.Context(), keyID)
+	})
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid token. Err: %v", err)
+	}
+
+	subject := subjectOf(claims)
+	if subject == "" {
+		return Principal{}, errors.New("invalid token. Err: token has no subject")
+	}
+	if len(a.allowedSubjects) > 0 && !a.allowedSubjects[subject] {
+		return Principal{}, fmt.Errorf("subject '%s' is not allowed", subject)
+	}
+	if application := applicationOf(claims); len(a.allowedApplications) > 0 && !a.allowedApplications[application] {
+		return Principal{}, fmt.Errorf("application '%s' is not allowed", application)
+	}
+
+	return Principal{Subject: subject, Method: MethodJWT, Scopes: scopesOf(claims)}, nil
+}
+
+// subjectOf prefers the object id of the caller, which Azure AD keeps across applications
+func subjectOf(claims jwt.MapClaims) string {
+	for _, claim := range []string{"oid", "sub"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// applicationOf returns the client application of the token, azp in v2.0 tokens and appid in v1.0 tokens
+func applicationOf(claims jwt.MapClaims) string {
+	for _, claim := range []string{"azp", "appid"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func scopesOf(claims jwt.MapClaims) []string {
+	scopes := []string{}
+
+	if scp, ok := claims["scp"].(string); ok {
+		scopes = append(scopes, strings.Fields(scp)...)
+	}
+
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if value, ok := role.(string); ok {
+				scopes = append(scopes, value)
+			}
+		}
+	}
+
+	return scopes
+}
@@ -0,0 +1,183 @@
+package backfill_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/backfill"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestRead(t *testing.T) {
+	startAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	expected := []backfill.Row{
+		{Line: 2, Event: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 2, StartAt: startAt}},
+		{Line: 3, Event: coreMetering.UsageEvent{DimensionID: "cpu", Quantity: 0.5, StartAt: startAt}},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		rows, err := backfill.Read(strings.NewReader(
+			"startAt,dimensionId,quantity\n2024-05-01T10:00:00Z,gpu,2\n2024-05-01T10:00:00Z, cpu, 0.5\n"),
+			backfill.FormatCSV)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, rows); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		rows, err := backfill.Read(strings.NewReader(
+			"\n"+
+				`{"dimensionId":"gpu","quantity":2,"startAt":"2024-05-01T10:00:00Z"}`+"\n"+
+				`{"dimensionId":"cpu","quantity":0.5,"startAt":"2024-05-01T10:00:00Z"}`+"\n"),
+			backfill.FormatNDJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, rows); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("reports the line of invalid rows", func(t *testing.T) {
+		_, err := backfill.Read(strings.NewReader("dimensionId,quantity,startAt\ngpu,two,2024-05-01T10:00:00Z\n"),
+			backfill.FormatCSV)
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("expected an error of line 2, got %v", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	row := func(line int, dimension string, quantity float32, startAt time.Time) backfill.Row {
+		return backfill.Row{
+			Line:  line,
+			Event: coreMetering.UsageEvent{DimensionID: dimension, Quantity: quantity, StartAt: startAt},
+		}
+	}
+
+	rows := []backfill.Row{
+		row(1, "gpu", 2, now.Add(-2*time.Hour)),
+		row(2, "", 2, now.Add(-2*time.Hour)),
+		row(3, "gpu", 0, now.Add(-2*time.Hour)),
+		row(4, "gpu", 2, now.Add(time.Hour)),
+		row(5, "gpu", 2, now.Add(-25*time.Hour)),
+		row(6, "gpu", 3, now.Add(-2*time.Hour).Add(10*time.Minute)),
+		row(7, "cpu", 0.1, now.Add(-time.Hour)),
+		row(8, "cpu", 2, now.Add(-time.Hour)),
+	}
+
+	configuration := metering.Configuration{DimensionSkipThresholds: map[string]float32{"cpu": 1}}
+	valid, issues := backfill.Validate(rows, configuration, 24*time.Hour, now)
+
+	lines := []int{}
+	for _, row := range valid {
+		lines = append(lines, row.Line)
+	}
+	if diff := cmp.Diff([]int{1, 8}, lines); diff != "" {
+		t.Fatalf("valid lines mismatch (-want +got):\n%s", diff)
+	}
+
+	invalid := []int{}
+	for _, issue := range issues {
+		invalid = append(invalid, issue.Line)
+	}
+	if diff := cmp.Diff([]int{2, 3, 4, 5, 6, 7}, invalid); diff != "" {
+		t.Fatalf("invalid lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	startAt := time.Now().UTC().Truncate(time.Hour)
+	rows := []backfill.Row{}
+	for line := 1; line <= 5; line++ {
+		rows = append(rows, backfill.Row{
+			Line:  line,
+			Event: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: float32(line), StartAt: startAt},
+		})
+	}
+
+	response := func(statuses ...string) *metering.UsageEventBatchResponse {
+		result := &metering.UsageEventBatchResponse{}
+		for _, status := range statuses {
+			result.Result = append(result.Result, metering.UsageEventResponse{
+				UsageEventResponse: coreMetering.UsageEventResponse{Status: status},
+			})
+		}
+		return result
+	}
+
+	path := filepath.Join(t.TempDir(), "rows.checkpoint")
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockMeteringClient(ctrl)
+
+	t.Run("stops on a failed batch", func(t *testing.T) {
+		checkpoint, err := backfill.LoadCheckpoint(path, "digest")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gomock.InOrder(
+			client.EXPECT().BatchCreateUsageEvent(gomock.Any(), gomock.Any()).
+				Return(response(metering.StatusAccepted, "Expired"), nil),
+			client.EXPECT().BatchCreateUsageEvent(gomock.Any(), gomock.Any()).
+				Return(nil, errors.New("unavailable")),
+		)
+
+		progress := []backfill.Progress{}
+		result, err := backfill.Submit(context.Background(), client, rows, checkpoint, 2, func(p backfill.Progress) {
+			progress = append(progress, p)
+		})
+		if err == nil {
+			t.Fatal("expected the failed batch to stop the backfill")
+		}
+		if result.Accepted != 1 || result.Failed != 1 || len(progress) != 1 {
+			t.Fatalf("unexpected result %+v, progress %+v", result, progress)
+		}
+	})
+
+	t.Run("resumes from the checkpoint", func(t *testing.T) {
+		checkpoint, err := backfill.LoadCheckpoint(path, "digest")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gomock.InOrder(
+			client.EXPECT().BatchCreateUsageEvent(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, batch coreMetering.UsageEventBatch) (*metering.UsageEventBatchResponse, error) {
+					if batch.Events[0].Quantity != 2 {
+						t.Fatalf("expected the batch to start with line 2, got quantity %v", batch.Events[0].Quantity)
+					}
+					return response(metering.StatusAccepted, metering.StatusDuplicate), nil
+				}),
+			client.EXPECT().BatchCreateUsageEvent(gomock.Any(), gomock.Any()).
+				Return(response(metering.StatusAccepted, metering.StatusAccepted), nil),
+		)
+
+		result, err := backfill.Submit(context.Background(), client, rows, checkpoint, 2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Resumed != 1 || result.Accepted != 4 || result.Failed != 0 {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	})
+
+	t.Run("rejects the checkpoint of another file", func(t *testing.T) {
+		if _, err := backfill.LoadCheckpoint(path, "another"); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
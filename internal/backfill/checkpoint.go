@@ -0,0 +1,97 @@
+// Package backfill provides the submission of usage events read from files, to correct the billing by hand
+package backfill
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Checkpoint records the lines of a file that azure already accepted, so an interrupted backfill
+// resumes without sending them again
+type Checkpoint struct {
+	path      string
+	digest    string
+	completed map[int]bool
+}
+
+type checkpointFile struct {
+	Digest string `json:"digest"`
+	Lines  []int  `json:"lines"`
+}
+
+// Digest returns the digest of the content of a file, which identifies it in the checkpoint
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadCheckpoint reads the checkpoint of the path, or starts an empty one when it doesn't exist.
+// It fails when the checkpoint belongs to a file with another digest.
+func LoadCheckpoint(path string, digest string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, digest: digest, completed: map[int]bool{}}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := checkpointFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint %s. Err: %v", path, err)
+	}
+	if file.Digest != digest {
+		return nil, fmt.Errorf("checkpoint %s belongs to another file, remove it to start over", path)
+	}
+
+	for _, line := range file.Lines {
+		c.completed[line] = true
+	}
+
+	return c, nil
+}
+
+// Completed returns true when the line was already accepted
+func (c *Checkpoint) Completed(line int) bool {
+	return c.completed[line]
+}
+
+// Complete records the lines as accepted and saves the checkpoint, when it has a path
+func (c *Checkpoint) Complete(lines ...int) error {
+	for _, line := range lines {
+		c.completed[line] = true
+	}
+
+	if c.path == "" {
+		return nil
+	}
+
+	file := checkpointFile{Digest: c.digest, Lines: make([]int, 0, len(c.completed))}
+	for line := range c.completed {
+		file.Lines = append(file.Lines, line)
+	}
+	sort.Ints(file.Lines)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// the rename replaces the previous checkpoint at once, so an interruption doesn't leave it truncated
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,137 @@
+// Package backfill provides the submission of usage events read from files, to correct the billing by hand
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+// Format defines the encoding of the usage rows of a file
+type Format string
+
+// Supported formats
+const (
+	// FormatCSV reads a header with the dimensionId, quantity and startAt columns, in any order
+	FormatCSV Format = "csv"
+	// FormatNDJSON reads an usage event in JSON per line
+	FormatNDJSON Format = "ndjson"
+)
+
+var csvColumns = []string{"dimensionId", "quantity", "startAt"}
+
+// Row represents an usage event of a file, with the line it was read from
+type Row struct {
+	Line  int                     `json:"line"`
+	Event coreMetering.UsageEvent `json:"event"`
+}
+
+// FormatFromPath returns the format of the file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unknown format of file %s, expected .csv, .ndjson or .jsonl", path)
+	}
+}
+
+// Read parses the rows of the reader, it fails on the first row that can't be parsed
+func Read(r io.Reader, format Format) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatNDJSON:
+		return readNDJSON(r)
+	default:
+		return nil, fmt.Errorf("invalid format '%s'", format)
+	}
+}
+
+func readCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the header. Err: %v", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range csvColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column '%s', the header must have %s", name, strings.Join(csvColumns, ","))
+		}
+	}
+
+	rows := []Row{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		quantity, err := strconv.ParseFloat(strings.TrimSpace(record[columns["quantity"]]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quantity '%s'", line, record[columns["quantity"]])
+		}
+
+		startAt, err := time.Parse(time.RFC3339, strings.TrimSpace(record[columns["startAt"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid startAt '%s', expected RFC 3339", line, record[columns["startAt"]])
+		}
+
+		rows = append(rows, Row{
+			Line: line,
+			Event: coreMetering.UsageEvent{
+				DimensionID: strings.TrimSpace(record[columns["dimensionId"]]),
+				Quantity:    float32(quantity),
+				StartAt:     startAt,
+			},
+		})
+	}
+}
+
+func readNDJSON(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+
+	rows := []Row{}
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		event := coreMetering.UsageEvent{}
+		if err := decoder.Decode(&event); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		rows = append(rows, Row{Line: line, Event: event})
+	}
+
+	return rows, scanner.Err()
+}
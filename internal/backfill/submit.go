@@ -0,0 +1,106 @@
+// Package backfill provides the submission of usage events read from files, to correct the billing by hand
+package backfill
+
+import (
+	"context"
+	"fmt"
+
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// MaxBatchSize is the limit of events of a batch of the marketplace metering API
+const MaxBatchSize = 25
+
+// Progress represents the rows submitted so far
+type Progress struct {
+	Total     int `json:"total"`
+	Resumed   int `json:"resumed"`
+	Submitted int `json:"submitted"`
+	Accepted  int `json:"accepted"`
+	Failed    int `json:"failed"`
+}
+
+// Result represents the outcome of a backfill, with the rows azure didn't accept
+type Result struct {
+	Progress
+	Failures []Issue `json:"failures,omitempty"`
+}
+
+// Submit sends the rows that aren't completed in the checkpoint in batches, recording the accepted ones after
+// every batch and reporting the progress. The rows azure didn't accept are kept to be retried on the next run.
+// It stops on the first batch that fails, so the backfill resumes from it.
+func Submit(
+	ctx context.Context,
+	client metering.Client,
+	rows []Row,
+	checkpoint *Checkpoint,
+	batchSize int,
+	report func(Progress),
+) (Result, error) {
+	if batchSize <= 0 || batchSize > MaxBatchSize {
+		return Result{}, fmt.Errorf("batch size must be between 1 and %d", MaxBatchSize)
+	}
+
+	result := Result{Progress: Progress{Total: len(rows)}}
+
+	pending := []Row{}
+	for _, row := range rows {
+		if checkpoint.Completed(row.Line) {
+			result.Resumed++
+			continue
+		}
+		pending = append(pending, row)
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+
+		events := coreMetering.UsageEventBatch{Events: make([]coreMetering.UsageEvent, 0, len(batch))}
+		for _, row := range batch {
+			events.Events = append(events.Events, row.Event)
+		}
+
+		response, err := client.BatchCreateUsageEvent(ctx, events)
+		if err != nil {
+			return result, fmt.Errorf("batch of lines %d to %d failed. Err: %v", batch[0].Line, batch[len(batch)-1].Line, err)
+		}
+
+		accepted := []int{}
+		for i, row := range batch {
+			status, reason := metering.StatusUnknown, "azure didn't return a result for this event"
+			if i < len(response.Result) {
+				status, reason = response.Result[i].Status, response.Result[i].Reason
+			}
+
+			switch status {
+			case metering.StatusAccepted, metering.StatusDuplicate:
+				accepted = append(accepted, row.Line)
+			default:
+				result.Failures = append(result.Failures, Issue{Line: row.Line, Reason: failureReason(status, reason)})
+			}
+		}
+
+		result.Submitted += len(batch)
+		result.Accepted += len(accepted)
+		result.Failed += len(batch) - len(accepted)
+
+		if err := checkpoint.Complete(accepted...); err != nil {
+			return result, fmt.Errorf("could not save the checkpoint. Err: %v", err)
+		}
+
+		if report != nil {
+			report(result.Progress)
+		}
+	}
+
+	return result, nil
+}
+
+func failureReason(status string, reason string) string {
+	if reason == "" {
+		return status
+	}
+	return fmt.Sprintf("%s: %s", status, reason)
+}
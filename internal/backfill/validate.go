@@ -0,0 +1,62 @@
+// Package backfill provides the submission of usage events read from files, to correct the billing by hand
+package backfill
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// Issue represents a row that won't be submitted and why
+type Issue struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Validate splits the rows into the ones to submit and the issues of the others, applying the rules of the
+// metering client: the skip thresholds, the start time normalization and a single event per dimension and hour.
+// The marketplace rejects the events older than the max age or in the future, so they are reported too.
+func Validate(
+	rows []Row, configuration metering.Configuration, maxAge time.Duration, now time.Time,
+) ([]Row, []Issue) {
+	valid := []Row{}
+	issues := []Issue{}
+	hours := map[string]int{}
+
+	for _, row := range rows {
+		event := row.Event
+		startAt := configuration.NormalizeStartTime(event.StartAt)
+
+		var reason string
+		switch {
+		case event.DimensionID == "":
+			reason = "missing dimensionId"
+		case event.Quantity <= 0:
+			reason = fmt.Sprintf("quantity %v is not positive", event.Quantity)
+		case startAt.After(now):
+			reason = fmt.Sprintf("startAt %s is in the future", startAt.Format(time.RFC3339))
+		case now.Sub(startAt) > maxAge:
+			reason = fmt.Sprintf("startAt %s is older than %v", startAt.Format(time.RFC3339), maxAge)
+		}
+		if reason == "" {
+			reason, _ = configuration.SkipReason(event.DimensionID, event.Quantity)
+		}
+		if reason == "" {
+			key := fmt.Sprintf("%s/%d", event.DimensionID, startAt.Truncate(time.Hour).Unix())
+			if first, ok := hours[key]; ok {
+				reason = fmt.Sprintf("same dimension and hour as line %d", first)
+			} else {
+				hours[key] = row.Line
+			}
+		}
+
+		if reason != "" {
+			issues = append(issues, Issue{Line: row.Line, Reason: reason})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	return valid, issues
+}
@@ -0,0 +1,346 @@
+// Package collector provides objects to compute usage from Prometheus endpoints and send it to metering
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// Collector defines an interface to periodically meter the usage exposed by Prometheus endpoints
+type Collector interface {
+	// Run scrapes every endpoint on its interval until the context is done
+	Run(ctx context.Context)
+	// Shutdown waits for Run to stop, sends the usage of the completed hours until the context is done
+	// and saves the rest, including the current hour, when the collector has a state path
+	Shutdown(ctx context.Context) error
+}
+
+type collector struct {
+	logger         logging.Logger
+	configuration  Configuration
+	meteringClient metering.Client
+	pl             coreHTTP.Pipeline
+
+	states  []*endpointState
+	stopped chan struct{}
+}
+
+// NewCollector initializes a collector for the configured endpoints, restoring the usage saved on shutdown
+func NewCollector(
+	logger logging.Logger, configuration Configuration, meteringClient metering.Client,
+) (Collector, error) {
+	states := make([]*endpointState, 0, len(configuration.Endpoints))
+	for _, endpoint := range configuration.Endpoints {
+		states = append(states, newEndpointState(endpoint))
+	}
+
+	if configuration.StatePath != "" {
+		if err := loadState(configuration.StatePath, states); err != nil {
+			return nil, err
+		}
+	}
+
+	return &collector{
+		logger:         logger,
+		configuration:  configuration,
+		meteringClient: meteringClient,
+		pl:             coreHTTP.NewPipeline(),
+		states:         states,
+		stopped:        make(chan struct{}),
+	}, nil
+}
+
+// Run scrapes every endpoint on its interval until the context is done
+func (c *collector) Run(ctx context.Context) {
+	defer close(c.stopped)
+
+	wg := sync.WaitGroup{}
+
+	for _, state := range c.states {
+		wg.Add(1)
+		go func(state *endpointState) {
+			defer wg.Done()
+			c.runEndpoint(ctx, state)
+		}(state)
+	}
+
+	wg.Wait()
+}
+
+// Shutdown waits for Run to stop, sends the usage of the completed hours until the context is done
+// and saves the rest, including the current hour, when the collector has a state path.
+// The current hour isn't sent, azure would reject the rest of it as a duplicate after a restart.
+func (c *collector) Shutdown(ctx context.Context) error {
+	select {
+	case <-c.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	now := time.Now().UTC()
+	for _, state := range c.states {
+		c.flush(ctx, state, state.completedHours(now), now)
+	}
+
+	if c.configuration.StatePath != "" {
+		if err := saveState(c.configuration.StatePath, c.states); err != nil {
+			c.logger.Errorf("collectors failed to save the usage that wasn't sent. Err: %v", err)
+			return err
+		}
+		return ctx.Err()
+	}
+
+	for _, state := range c.states {
+		if pending := state.hoursBefore(now.Add(time.Hour)); len(pending) > 0 {
+			c.logger.Errorf("collector '%s' dropped usage of %d hours on shutdown, it requires a state path",
+				state.endpoint.Name, len(pending))
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (c *collector) runEndpoint(ctx context.Context, state *endpointState) {
+	endpoint := state.endpoint
+	c.logger.Infof("starting collector '%s' for %s every %v", endpoint.Name, endpoint.URL, endpoint.Interval)
+
+	ticker := time.NewTicker(endpoint.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+
+		if err := c.scrape(ctx, state, now); err != nil {
+			c.logger.Errorf("collector '%s' failed to scrape %s. Err: %v", endpoint.Name, endpoint.URL, err)
+		}
+
+		c.flush(ctx, state, state.completedHours(now), now)
+
+		select {
+		case <-ctx.Done():
+			c.logger.Infof("collector '%s' stopped", endpoint.Name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *collector) scrape(ctx context.Context, state *endpointState, now time.Time) error {
+	tCtx, cancel := context.WithTimeout(ctx, c.configuration.ScrapeTimeout)
+	defer cancel()
+
+	req, err := coreHTTP.NewRequest(tCtx, http.MethodGet, state.endpoint.URL)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := c.pl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !resp.HasStatusCode(http.StatusOK) {
+		return fmt.Errorf("request failed with error %s", resp.Status)
+	}
+
+	samples, err := parseSamples(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	for _, metric := range state.record(samples, now) {
+		c.logger.Warnf("collector '%s' ignored metric '%s', its value isn't a finite number",
+			state.endpoint.Name, metric.Name)
+	}
+
+	return nil
+}
+
+// flush sends the quantities of the hours, keeping them to be retried when the request fails
+func (c *collector) flush(ctx context.Context, state *endpointState, hours []time.Time, now time.Time) {
+	for _, hour := range hours {
+		if now.Sub(hour) > c.configuration.MaxEventAge {
+			c.logger.Errorf("collector '%s' dropped usage of %s, it is older than %v",
+				state.endpoint.Name, hour.Format(metering.TimeLayout), c.configuration.MaxEventAge)
+			state.remove(hour)
+			continue
+		}
+
+		events := state.events(hour)
+		if len(events) == 0 {
+			state.remove(hour)
+			continue
+		}
+
+		response, err := c.meteringClient.BatchCreateUsageEvent(ctx, coreMetering.UsageEventBatch{Events: events})
+		if err != nil {
+			c.logger.Errorf("collector '%s' failed to send usage of %s, it will be retried. Err: %v",
+				state.endpoint.Name, hour.Format(metering.TimeLayout), err)
+			return
+		}
+
+		for _, result := range response.Result {
+			c.logger.Infof("collector '%s' sent usage of '%s' for %s with status %s",
+				state.endpoint.Name, result.DimensionID, hour.Format(metering.TimeLayout), result.Status)
+		}
+
+		state.remove(hour)
+	}
+}
+
+type bucketKey struct {
+	metric int
+	hour   time.Time
+}
+
+// bucket accumulates the samples of a metric during an hour
+type bucket struct {
+	sum     float64
+	samples int
+}
+
+type endpointState struct {
+	endpoint EndpointConfiguration
+	counters map[int]float64
+	buckets  map[bucketKey]*bucket
+}
+
+func newEndpointState(endpoint EndpointConfiguration) *endpointState {
+	return &endpointState{
+		endpoint: endpoint,
+		counters: map[int]float64{},
+		buckets:  map[bucketKey]*bucket{},
+	}
+}
+
+// record evaluates the samples for each metric, counters record the increase since the previous scrape,
+// taking resets into account, while gauges record the value.
+// It returns the metrics whose value is NaN or infinite, which aren't recorded.
+func (s *endpointState) record(samples []sample, now time.Time) []MetricConfiguration {
+	hour := now.Truncate(time.Hour)
+	ignored := []MetricConfiguration{}
+
+	for i, metric := range s.endpoint.Metrics {
+		found := false
+		value := 0.0
+		for _, sample := range samples {
+			if sample.matches(metric) {
+				found = true
+				value += sample.value
+			}
+		}
+
+		if !found {
+			continue
+		}
+
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			ignored = append(ignored, metric)
+			continue
+		}
+
+		if metric.Type == Counter {
+			previous, ok := s.counters[i]
+			s.counters[i] = value
+			if !ok {
+				continue
+			}
+
+			if value >= previous {
+				value -= previous
+			}
+		}
+
+		key := bucketKey{metric: i, hour: hour}
+		if _, ok := s.buckets[key]; !ok {
+			s.buckets[key] = &bucket{}
+		}
+		s.buckets[key].sum += value
+		s.buckets[key].samples++
+	}
+
+	return ignored
+}
+
+// completedHours returns the hours with recorded samples before the current one, sorted
+func (s *endpointState) completedHours(now time.Time) []time.Time {
+	return s.hoursBefore(now.Truncate(time.Hour))
+}
+
+// hoursBefore returns the hours with recorded samples before the time, sorted
+func (s *endpointState) hoursBefore(t time.Time) []time.Time {
+	unique := map[time.Time]bool{}
+	for key := range s.buckets {
+		if key.hour.Before(t) {
+			unique[key.hour] = true
+		}
+	}
+
+	hours := make([]time.Time, 0, len(unique))
+	for hour := range unique {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+
+	return hours
+}
+
+// events computes the quantity of each dimension during the hour
+func (s *endpointState) events(hour time.Time) []coreMetering.UsageEvent {
+	quantities := map[string]float64{}
+	dimensions := []string{}
+
+	for i, metric := range s.endpoint.Metrics {
+		bucket, ok := s.buckets[bucketKey{metric: i, hour: hour}]
+		if !ok || bucket.samples == 0 {
+			continue
+		}
+
+		quantity := bucket.sum
+		if metric.Type == Gauge {
+			quantity /= float64(bucket.samples)
+		}
+
+		factor := metric.Factor
+		if factor == 0 {
+			factor = 1
+		}
+
+		if _, ok := quantities[metric.Dimension]; !ok {
+			dimensions = append(dimensions, metric.Dimension)
+		}
+		quantities[metric.Dimension] += quantity * factor
+	}
+
+	events := make([]coreMetering.UsageEvent, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		events = append(events, coreMetering.UsageEvent{
+			DimensionID: dimension,
+			Quantity:    float32(quantities[dimension]),
+			StartAt:     hour,
+		})
+	}
+
+	return events
+}
+
+func (s *endpointState) remove(hour time.Time) {
+	for key := range s.buckets {
+		if key.hour.Equal(hour) {
+			delete(s.buckets, key)
+		}
+	}
+}
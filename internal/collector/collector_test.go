@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestConfigurationValidate(t *testing.T) {
+	endpoint := func(name string, metrics ...MetricConfiguration) EndpointConfiguration {
+		return EndpointConfiguration{
+			Name: name, URL: "http://" + name + "/metrics", Interval: Duration{time.Minute}, Metrics: metrics,
+		}
+	}
+	gpu := MetricConfiguration{Dimension: "gpu", Name: "gpu_in_use", Type: Gauge}
+	cpu := MetricConfiguration{Dimension: "cpu", Name: "cpu_in_use", Type: Gauge}
+
+	tt := []struct {
+		name      string
+		endpoints []EndpointConfiguration
+		valid     bool
+	}{
+		{name: "valid", endpoints: []EndpointConfiguration{endpoint("a", gpu), endpoint("b", cpu)}, valid: true},
+		{name: "dimension on several endpoints", endpoints: []EndpointConfiguration{endpoint("a", gpu), endpoint("b", gpu)}},
+		{name: "endpoint names", endpoints: []EndpointConfiguration{endpoint("a", gpu), endpoint("a", cpu)}},
+		{name: "NaN factor", endpoints: []EndpointConfiguration{endpoint("a", MetricConfiguration{
+			Dimension: "gpu", Name: "gpu_in_use", Type: Gauge, Factor: math.NaN(),
+		})}},
+		{name: "infinite factor", endpoints: []EndpointConfiguration{endpoint("a", MetricConfiguration{
+			Dimension: "gpu", Name: "gpu_in_use", Type: Gauge, Factor: math.Inf(1),
+		})}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Configuration{Endpoints: tc.endpoints}.validate()
+			if tc.valid != (err == nil) {
+				t.Fatalf("expected valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestShutdownKeepsCurrentHour(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now().UTC()
+	current, previous := now.Truncate(time.Hour), now.Truncate(time.Hour).Add(-time.Hour)
+
+	// only the completed hour is sent
+	meteringClient := mock.NewMockMeteringClient(ctrl)
+	meteringClient.EXPECT().
+		BatchCreateUsageEvent(gomock.Any(), coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{
+			{DimensionID: "gpu", Quantity: 2, StartAt: previous},
+		}}).
+		Return(&metering.UsageEventBatchResponse{}, nil)
+
+	configuration := Configuration{
+		MaxEventAge: 24 * time.Hour,
+		StatePath:   filepath.Join(t.TempDir(), "collectors.json"),
+		Endpoints: []EndpointConfiguration{{
+			Name:    "gpu",
+			Metrics: []MetricConfiguration{{Dimension: "gpu", Name: "gpu_in_use", Type: Gauge}},
+		}},
+	}
+
+	first, err := NewCollector(logger, configuration, meteringClient)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	state := first.(*collector).states[0]
+	if ignored := state.record([]sample{{name: "gpu_in_use", value: 2}}, previous); len(ignored) != 0 {
+		t.Fatalf("should not ignore the metric, got %+v", ignored)
+	}
+	state.record([]sample{{name: "gpu_in_use", value: 3}}, current)
+	if ignored := state.record([]sample{{name: "gpu_in_use", value: math.NaN()}}, current); len(ignored) != 1 {
+		t.Fatalf("should ignore the NaN value, got %+v", ignored)
+	}
+
+	close(first.(*collector).stopped)
+	if err := first.Shutdown(context.Background()); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	second, err := NewCollector(logger, configuration, meteringClient)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	expected := []coreMetering.UsageEvent{{DimensionID: "gpu", Quantity: 3, StartAt: current}}
+	if diff := cmp.Diff(expected, second.(*collector).states[0].events(current)); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,146 @@
+// Package collector provides objects to compute usage from Prometheus endpoints and send it to metering
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// MetricType defines how the samples of a metric are turned into an hourly quantity
+type MetricType string
+
+// Supported metric types
+const (
+	// Counter quantity is the increase of the counter during the hour
+	Counter MetricType = "counter"
+	// Gauge quantity is the average value of the gauge during the hour
+	Gauge MetricType = "gauge"
+)
+
+// Configuration represents the configuration of the metering collectors.
+type Configuration struct {
+	File          string        `envconfig:"METERING_COLLECTORS_FILE" default:""`
+	ScrapeTimeout time.Duration `envconfig:"METERING_COLLECTORS_SCRAPE_TIMEOUT" default:"10s"`
+	MaxEventAge   time.Duration `envconfig:"METERING_COLLECTORS_MAX_EVENT_AGE" default:"24h"`
+	// StatePath keeps the usage that wasn't sent on shutdown, including the current hour, which is restored on start.
+	// Without it the current hour is dropped on shutdown, since sending it would make azure reject the rest
+	// of the hour as a duplicate after the restart.
+	StatePath string `envconfig:"METERING_COLLECTORS_STATE_PATH" default:""`
+
+	Endpoints []EndpointConfiguration `ignored:"true"`
+}
+
+// EndpointConfiguration represents a Prometheus text-format endpoint to scrape periodically
+type EndpointConfiguration struct {
+	Name     string                `json:"name"`
+	URL      string                `json:"url"`
+	Interval Duration              `json:"interval"`
+	Metrics  []MetricConfiguration `json:"metrics"`
+}
+
+// MetricConfiguration represents a metric evaluated into the quantity of a metering dimension.
+// The samples with the same name and all the configured labels are summed before being evaluated.
+type MetricConfiguration struct {
+	Dimension string            `json:"dimension"`
+	Name      string            `json:"name"`
+	Type      MetricType        `json:"type"`
+	Labels    map[string]string `json:"labels"`
+	Factor    float64           `json:"factor"`
+}
+
+// Duration is a time.Duration that is represented as a string in the configuration file, e.g. "30s"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+
+	return nil
+}
+
+// LoadFromEnvVars reads all env vars required for the collectors and the endpoints from the configured file.
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	if c.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+
+	file := struct {
+		Endpoints []EndpointConfiguration `json:"endpoints"`
+	}{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("could not parse collectors file %s. Err: %v", c.File, err)
+	}
+	c.Endpoints = file.Endpoints
+
+	return c.validate()
+}
+
+func (c Configuration) validate() error {
+	// the quantities of a dimension are sent once per hour, so several endpoints would send duplicate events
+	dimensions := map[string]string{}
+	names := map[string]bool{}
+
+	for _, endpoint := range c.Endpoints {
+		if endpoint.Name == "" || endpoint.URL == "" {
+			return fmt.Errorf("collector endpoints require a name and an url")
+		}
+
+		if names[endpoint.Name] {
+			return fmt.Errorf("collector '%s' is configured more than once", endpoint.Name)
+		}
+		names[endpoint.Name] = true
+
+		if endpoint.Interval.Duration <= 0 || endpoint.Interval.Duration > time.Hour {
+			return fmt.Errorf("collector '%s' interval must be between 0 and 1h", endpoint.Name)
+		}
+
+		for _, metric := range endpoint.Metrics {
+			if metric.Dimension == "" || metric.Name == "" {
+				return fmt.Errorf("collector '%s' metrics require a dimension and a name", endpoint.Name)
+			}
+
+			if metric.Type != Counter && metric.Type != Gauge {
+				return fmt.Errorf("collector '%s' metric '%s' has an invalid type '%s'",
+					endpoint.Name, metric.Name, metric.Type)
+			}
+
+			if math.IsNaN(metric.Factor) || math.IsInf(metric.Factor, 0) || metric.Factor < 0 {
+				return fmt.Errorf("collector '%s' metric '%s' factor must be a positive number",
+					endpoint.Name, metric.Name)
+			}
+
+			if other, ok := dimensions[metric.Dimension]; ok && other != endpoint.Name {
+				return fmt.Errorf("collector '%s' dimension '%s' is already collected by '%s'",
+					endpoint.Name, metric.Dimension, other)
+			}
+			dimensions[metric.Dimension] = endpoint.Name
+		}
+	}
+
+	return nil
+}
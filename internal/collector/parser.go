@@ -0,0 +1,143 @@
+// Package collector provides objects to compute usage from Prometheus endpoints and send it to metering
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sample represents a single sample of the Prometheus text exposition format
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// matches returns true when the sample has the name and all the labels of the metric
+func (s sample) matches(metric MetricConfiguration) bool {
+	if s.name != metric.Name {
+		return false
+	}
+
+	for key, value := range metric.Labels {
+		if s.labels[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseSamples parses the Prometheus text exposition format, ignoring comments and timestamps
+func parseSamples(reader io.Reader) ([]sample, error) {
+	samples := []sample{}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, scanner.Err()
+}
+
+func parseSample(line string) (sample, error) {
+	s := sample{labels: map[string]string{}}
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return sample{}, fmt.Errorf("invalid sample '%s'", line)
+	}
+	s.name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseLabels(rest[1:])
+		if err != nil {
+			return sample{}, err
+		}
+		s.labels = labels
+		rest = remaining
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample{}, fmt.Errorf("invalid value in sample '%s'", line)
+	}
+
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return sample{}, err
+	}
+	s.value = value
+
+	return s, nil
+}
+
+// parseLabels parses the labels until the closing brace and returns the remaining of the line
+func parseLabels(line string) (map[string]string, string, error) {
+	labels := map[string]string{}
+
+	for {
+		line = strings.TrimLeft(line, " \t,")
+		if strings.HasPrefix(line, "}") {
+			return labels, line[1:], nil
+		}
+
+		equal := strings.Index(line, "=")
+		if equal <= 0 || len(line) < equal+2 || line[equal+1] != '"' {
+			return nil, "", fmt.Errorf("invalid labels '%s'", line)
+		}
+		key := strings.TrimSpace(line[:equal])
+
+		value := strings.Builder{}
+		i := equal + 2
+		for ; i < len(line) && line[i] != '"'; i++ {
+			if line[i] == '\\' && i+1 < len(line) {
+				i++
+				switch line[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(line[i])
+				}
+				continue
+			}
+			value.WriteByte(line[i])
+		}
+
+		if i >= len(line) {
+			return nil, "", fmt.Errorf("unterminated label value of '%s'", key)
+		}
+
+		labels[key] = value.String()
+		line = line[i+1:]
+	}
+}
+
+func parseValue(value string) (float64, error) {
+	switch value {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
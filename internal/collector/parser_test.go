@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+const exposition = `# HELP gpu_in_use number of GPUs allocated
+# TYPE gpu_in_use gauge
+gpu_in_use{node="a",pool="gpu"} 2
+gpu_in_use{node="b",pool="gpu"} 1
+gpu_in_use{node="c",pool="cpu"} 5
+# TYPE gpu_seconds_total counter
+gpu_seconds_total{path="C:\\data",msg="say \"hi\""} 7200 1714557600000
+`
+
+func TestParseSamples(t *testing.T) {
+	samples, err := parseSamples(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	expected := []sample{
+		{name: "gpu_in_use", labels: map[string]string{"node": "a", "pool": "gpu"}, value: 2},
+		{name: "gpu_in_use", labels: map[string]string{"node": "b", "pool": "gpu"}, value: 1},
+		{name: "gpu_in_use", labels: map[string]string{"node": "c", "pool": "cpu"}, value: 5},
+		{name: "gpu_seconds_total", labels: map[string]string{"path": `C:\data`, "msg": `say "hi"`}, value: 7200},
+	}
+
+	if diff := cmp.Diff(expected, samples, cmp.AllowUnexported(sample{})); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEndpointStateEvents(t *testing.T) {
+	state := newEndpointState(EndpointConfiguration{
+		Metrics: []MetricConfiguration{
+			{Dimension: "gpu_hours", Name: "gpu_in_use", Type: Gauge, Labels: map[string]string{"pool": "gpu"}},
+			{Dimension: "gpu_time", Name: "gpu_seconds_total", Type: Counter, Factor: 1.0 / 3600},
+		},
+	})
+
+	hour := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	scrapes := []struct {
+		gauge   float64
+		counter float64
+	}{
+		{gauge: 2, counter: 3600},
+		{gauge: 4, counter: 7200},
+		// the counter was reset
+		{gauge: 6, counter: 1800},
+	}
+
+	for i, scrape := range scrapes {
+		state.record([]sample{
+			{name: "gpu_in_use", labels: map[string]string{"pool": "gpu"}, value: scrape.gauge},
+			{name: "gpu_in_use", labels: map[string]string{"pool": "cpu"}, value: 100},
+			{name: "gpu_seconds_total", value: scrape.counter},
+		}, hour.Add(time.Duration(i)*time.Minute))
+	}
+
+	if diff := cmp.Diff([]time.Time{hour}, state.completedHours(hour.Add(time.Hour))); diff != "" {
+		t.Fatal(diff)
+	}
+
+	expected := []coreMetering.UsageEvent{
+		{DimensionID: "gpu_hours", Quantity: 4, StartAt: hour},
+		{DimensionID: "gpu_time", Quantity: 1.5, StartAt: hour},
+	}
+
+	if diff := cmp.Diff(expected, state.events(hour)); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,126 @@
+// Package collector provides objects to compute usage from Prometheus endpoints and send it to metering
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// savedState represents the usage of the endpoints that wasn't sent when the collector stopped
+type savedState struct {
+	Endpoints map[string]savedEndpoint `json:"endpoints"`
+}
+
+type savedEndpoint struct {
+	Metrics []savedMetric `json:"metrics"`
+}
+
+// savedMetric is restored when the metric at the same position of the endpoint has the same dimension and name
+type savedMetric struct {
+	Dimension string        `json:"dimension"`
+	Name      string        `json:"name"`
+	Counter   *float64      `json:"counter,omitempty"`
+	Buckets   []savedBucket `json:"buckets"`
+}
+
+type savedBucket struct {
+	Hour    time.Time `json:"hour"`
+	Sum     float64   `json:"sum"`
+	Samples int       `json:"samples"`
+}
+
+// loadState restores the saved usage into the states of the endpoints, when the file exists
+func loadState(path string, states []*endpointState) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	saved := savedState{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("could not parse collectors state %s. Err: %v", path, err)
+	}
+
+	for _, state := range states {
+		endpoint, ok := saved.Endpoints[state.endpoint.Name]
+		if !ok {
+			continue
+		}
+
+		for i, metric := range endpoint.Metrics {
+			if i >= len(state.endpoint.Metrics) ||
+				state.endpoint.Metrics[i].Dimension != metric.Dimension || state.endpoint.Metrics[i].Name != metric.Name {
+				continue
+			}
+
+			if metric.Counter != nil {
+				state.counters[i] = *metric.Counter
+			}
+			for _, saved := range metric.Buckets {
+				state.buckets[bucketKey{metric: i, hour: saved.Hour}] = &bucket{sum: saved.Sum, samples: saved.Samples}
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveState replaces the file with the usage of the endpoints that wasn't sent
+func saveState(path string, states []*endpointState) error {
+	saved := savedState{Endpoints: map[string]savedEndpoint{}}
+
+	for _, state := range states {
+		endpoint := savedEndpoint{}
+		for i, metric := range state.endpoint.Metrics {
+			savedMetric := savedMetric{Dimension: metric.Dimension, Name: metric.Name, Buckets: []savedBucket{}}
+			if counter, ok := state.counters[i]; ok {
+				savedMetric.Counter = &counter
+			}
+			for key, bucket := range state.buckets {
+				if key.metric == i {
+					savedMetric.Buckets = append(savedMetric.Buckets,
+						savedBucket{Hour: key.hour, Sum: bucket.sum, Samples: bucket.samples})
+				}
+			}
+			sort.Slice(savedMetric.Buckets, func(a, b int) bool {
+				return savedMetric.Buckets[a].Hour.Before(savedMetric.Buckets[b].Hour)
+			})
+			endpoint.Metrics = append(endpoint.Metrics, savedMetric)
+		}
+		saved.Endpoints[state.endpoint.Name] = endpoint
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced at once, so a failure leaves the previous state
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
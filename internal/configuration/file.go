@@ -0,0 +1,188 @@
+// Package configuration provides objects to configure adapter objects
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+var envVarPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// File represents a YAML or JSON configuration file layered under the env vars.
+// Its keys are the names of the env vars, which take precedence over the file, and its values are scalars,
+// lists or maps of scalars, e.g. METERING_DIMENSION_SKIP_THRESHOLDS: {gpu: 0.5} or
+// AUTH_BEARER_TOKENS: {token: metering:write quota:read}.
+// It must be loaded before the other configurations so they see the values of the file.
+type File struct {
+	Path           string        `envconfig:"CONFIG_FILE" default:""`
+	ReloadInterval time.Duration `envconfig:"CONFIG_RELOAD_INTERVAL" default:"10s"`
+
+	mu sync.Mutex
+	// environment has the env vars set by the environment, which are never overridden
+	environment map[string]bool
+	// applied has the env vars set from the file
+	applied map[string]bool
+}
+
+// LoadFromEnvVars reads the configuration file and sets the env vars that aren't set by the environment
+func (c *File) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	if c.Path == "" {
+		return nil
+	}
+
+	c.environment = map[string]bool{}
+	for _, env := range os.Environ() {
+		c.environment[strings.SplitN(env, "=", 2)[0]] = true
+	}
+	c.applied = map[string]bool{}
+
+	values, err := ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.apply(values)
+	return err
+}
+
+// apply sets the env vars from the values of the file and returns a function that restores the previous ones,
+// so a reload can be rolled back when the configurations don't accept the new values
+func (c *File) apply(values map[string]string) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := map[string]string{}
+	for key := range c.applied {
+		previous[key] = os.Getenv(key)
+	}
+
+	if err := c.setenv(values); err != nil {
+		c.setenv(previous)
+		return nil, err
+	}
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.setenv(previous)
+	}, nil
+}
+
+// setenv sets the env vars that aren't set by the environment and unsets the ones applied before
+// that aren't in the values
+func (c *File) setenv(values map[string]string) error {
+	for key := range c.applied {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(c.applied, key)
+		}
+	}
+
+	for key, value := range values {
+		if c.environment[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+		c.applied[key] = true
+	}
+
+	return nil
+}
+
+// ReadFile parses a YAML or JSON configuration file into the values of the env vars
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s. Err: %v", path, err)
+	}
+
+	values := map[string]string{}
+	for key, value := range document {
+		if !envVarPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid key '%s' in configuration file %s, keys are env var names", key, path)
+		}
+
+		envValue, err := envValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of '%s' in configuration file %s. Err: %v", key, path, err)
+		}
+		values[key] = envValue
+	}
+
+	return values, nil
+}
+
+// envValue converts a value of the file into the representation of envconfig
+func envValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			scalar, err := scalarValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, scalar)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		scalars := make(map[string]string, len(v))
+		colons := false
+		for key, item := range v {
+			scalar, err := scalarValue(item)
+			if err != nil {
+				return "", err
+			}
+			scalars[key] = scalar
+			colons = colons || strings.Contains(key+scalar, ":")
+		}
+
+		// envconfig separates the keys of the maps from their values with ":", so the maps that have one
+		// use the encoding of the auth scopes, key=value;key=value
+		separator, entrySeparator := ":", ","
+		if colons {
+			separator, entrySeparator = "=", ";"
+		}
+
+		entries := make([]string, 0, len(scalars))
+		for key, scalar := range scalars {
+			entries = append(entries, key+separator+scalar)
+		}
+		sort.Strings(entries)
+		return strings.Join(entries, entrySeparator), nil
+	default:
+		return scalarValue(value)
+	}
+}
+
+func scalarValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v, only scalars, lists and maps of scalars are supported", value)
+	}
+}
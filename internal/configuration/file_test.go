@@ -0,0 +1,188 @@
+package configuration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+)
+
+func TestFile(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := []string{"TEST_CONFIG_MACHINE_TYPE", "TEST_CONFIG_THRESHOLDS", "TEST_CONFIG_METHODS", "TEST_CONFIG_LOCATION"}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+	})
+
+	write(`
+TEST_CONFIG_MACHINE_TYPE: standardNCFamily
+TEST_CONFIG_THRESHOLDS: {gpu: 0.5, cpu: 1}
+TEST_CONFIG_METHODS: [bearer, jwt]
+TEST_CONFIG_LOCATION: westeurope
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("TEST_CONFIG_LOCATION", "northeurope")
+
+	file := &File{}
+	if err := file.LoadFromEnvVars(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	env := func() map[string]string {
+		values := map[string]string{}
+		for _, key := range keys {
+			values[key] = os.Getenv(key)
+		}
+		return values
+	}
+
+	expected := map[string]string{
+		"TEST_CONFIG_MACHINE_TYPE": "standardNCFamily",
+		"TEST_CONFIG_THRESHOLDS":   "cpu:1,gpu:0.5",
+		"TEST_CONFIG_METHODS":      "bearer,jwt",
+		"TEST_CONFIG_LOCATION":     "northeurope",
+	}
+	if diff := cmp.Diff(expected, env()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	reloads := 0
+	w := NewWatcher(logger, file).(*watcher)
+	w.OnReload(func() (func(), error) {
+		if os.Getenv("TEST_CONFIG_MACHINE_TYPE") == "" {
+			return nil, errors.New("missing machine type")
+		}
+		return func() { reloads++ }, nil
+	})
+
+	t.Run("reloads the changed file", func(t *testing.T) {
+		write(`
+TEST_CONFIG_MACHINE_TYPE: standardNDFamily
+TEST_CONFIG_LOCATION: westus
+`)
+		w.poll()
+
+		expected := map[string]string{
+			"TEST_CONFIG_MACHINE_TYPE": "standardNDFamily",
+			"TEST_CONFIG_THRESHOLDS":   "",
+			"TEST_CONFIG_METHODS":      "",
+			"TEST_CONFIG_LOCATION":     "northeurope",
+		}
+		if diff := cmp.Diff(expected, env()); diff != "" {
+			t.Fatal(diff)
+		}
+
+		if reloads != 1 {
+			t.Fatalf("should reload once, got %d", reloads)
+		}
+	})
+
+	t.Run("keeps the configuration when the file is invalid", func(t *testing.T) {
+		write(`test_config_machine_type: standardNVFamily`)
+		w.poll()
+
+		if value := os.Getenv("TEST_CONFIG_MACHINE_TYPE"); value != "standardNDFamily" {
+			t.Fatalf("should keep the previous value, got %s", value)
+		}
+
+		if reloads != 1 {
+			t.Fatalf("should not reload, got %d", reloads)
+		}
+	})
+
+	t.Run("restores the env when a configuration is invalid", func(t *testing.T) {
+		write(`
+TEST_CONFIG_THRESHOLDS: {gpu: 1}
+TEST_CONFIG_LOCATION: westus
+`)
+		w.poll()
+
+		expected := map[string]string{
+			"TEST_CONFIG_MACHINE_TYPE": "standardNDFamily",
+			"TEST_CONFIG_THRESHOLDS":   "",
+			"TEST_CONFIG_METHODS":      "",
+			"TEST_CONFIG_LOCATION":     "northeurope",
+		}
+		if diff := cmp.Diff(expected, env()); diff != "" {
+			t.Fatal(diff)
+		}
+
+		if _, ok := os.LookupEnv("TEST_CONFIG_THRESHOLDS"); ok {
+			t.Fatal("should unset the values of the rejected file")
+		}
+
+		if reloads != 1 {
+			t.Fatalf("should not reload, got %d", reloads)
+		}
+	})
+
+	t.Run("ignores unchanged files", func(t *testing.T) {
+		w.poll()
+
+		if reloads != 1 {
+			t.Fatalf("should not reload, got %d", reloads)
+		}
+	})
+}
+
+func TestFileAuthScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+AUTH_METHODS: [bearer, hmac]
+AUTH_BEARER_TOKENS:
+  token: metering:write quota:read
+  other: quota:read
+AUTH_HMAC_SCOPES:
+  partner: metering:write
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		for _, key := range []string{"AUTH_METHODS", "AUTH_BEARER_TOKENS", "AUTH_HMAC_SCOPES"} {
+			os.Unsetenv(key)
+		}
+	})
+	t.Setenv("CONFIG_FILE", path)
+
+	file := &File{}
+	if err := file.LoadFromEnvVars(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	configuration := auth.Configuration{}
+	if err := configuration.LoadFromEnvVars(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	expectedTokens := auth.ScopeMap{"token": "metering:write quota:read", "other": "quota:read"}
+	if diff := cmp.Diff(expectedTokens, configuration.BearerTokens); diff != "" {
+		t.Fatal(diff)
+	}
+
+	expectedScopes := auth.ScopeMap{"partner": "metering:write"}
+	if diff := cmp.Diff(expectedScopes, configuration.HMACScopes); diff != "" {
+		t.Fatal(diff)
+	}
+}
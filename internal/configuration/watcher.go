@@ -0,0 +1,136 @@
+// Package configuration provides objects to configure adapter objects
+package configuration
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// Watcher defines an interface to reload the configurations when the configuration files change
+type Watcher interface {
+	// OnReload registers a function that loads and validates a configuration into new objects and returns
+	// the function that applies it. The configurations are applied only when all of them are valid.
+	OnReload(load func() (func(), error))
+	// Run polls the files on the reload interval until the context is done
+	Run(ctx context.Context)
+}
+
+type watcher struct {
+	logger  logging.Logger
+	file    *File
+	paths   []string
+	loaders []func() (func(), error)
+	digests map[string][sha256.Size]byte
+}
+
+// NewWatcher initializes a watcher of the configuration file and the other files the configurations read,
+// e.g. the tenants file. The files are polled, since they are usually mounted from config maps.
+func NewWatcher(logger logging.Logger, file *File, paths ...string) Watcher {
+	if file.Path != "" {
+		paths = append([]string{file.Path}, paths...)
+	}
+
+	w := &watcher{
+		logger:  logger,
+		file:    file,
+		digests: map[string][sha256.Size]byte{},
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		w.paths = append(w.paths, path)
+		w.digests[path], _ = digest(path)
+	}
+
+	return w
+}
+
+// OnReload registers a function that loads and validates a configuration into new objects and returns
+// the function that applies it. The configurations are applied only when all of them are valid.
+func (w *watcher) OnReload(load func() (func(), error)) {
+	w.loaders = append(w.loaders, load)
+}
+
+// Run polls the files on the reload interval until the context is done
+func (w *watcher) Run(ctx context.Context) {
+	if len(w.paths) == 0 || w.file.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.file.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll reloads the configurations when any file changed since the previous poll
+func (w *watcher) poll() {
+	changed := false
+	for _, path := range w.paths {
+		current, err := digest(path)
+		if err != nil {
+			w.logger.Errorf("could not read configuration file %s. Err: %v", path, err)
+			continue
+		}
+
+		if current != w.digests[path] {
+			w.digests[path] = current
+			changed = true
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	restore := func() {}
+	if w.file.Path != "" {
+		values, err := ReadFile(w.file.Path)
+		if err != nil {
+			w.logger.Errorf("configuration file is invalid, keeping the previous configuration. Err: %v", err)
+			return
+		}
+
+		if restore, err = w.file.apply(values); err != nil {
+			w.logger.Errorf("could not apply the configuration file, keeping the previous configuration. Err: %v", err)
+			return
+		}
+	}
+
+	w.logger.Info("configuration files changed, reloading the configuration")
+	applies := make([]func(), 0, len(w.loaders))
+	for _, load := range w.loaders {
+		apply, err := load()
+		if err != nil {
+			restore()
+			w.logger.Errorf("configuration is invalid, keeping the previous configuration. Err: %v", err)
+			return
+		}
+		applies = append(applies, apply)
+	}
+
+	for _, apply := range applies {
+		apply()
+	}
+}
+
+func digest(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
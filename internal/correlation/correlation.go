@@ -0,0 +1,82 @@
+// Package correlation provides the ids that tie the logs of a request to the logs and calls of the clients it uses
+package correlation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/logs"
+)
+
+// Headers of the ids, the request id is sent to azure as x-ms-requestid
+const (
+	RequestIDHeader      = "X-Request-ID"
+	CorrelationIDHeader  = "x-ms-correlationid"
+	AzureRequestIDHeader = "x-ms-requestid"
+)
+
+// ginRequestIDKey is the key of the request id the server of go-core sets in the context of every request
+const ginRequestIDKey = "X-Request-Id"
+
+// IDs represents the ids of a request, the correlation id is shared by the requests of the same operation
+type IDs struct {
+	RequestID     string
+	CorrelationID string
+}
+
+type idsKey struct{}
+
+// NewID returns a new id
+func NewID() string {
+	return uuid.New().String()
+}
+
+// WithIDs returns a copy of the context with the ids
+func WithIDs(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+// FromContext returns the ids of the context, or of the gin context of a request, false when it has none
+func FromContext(ctx context.Context) (IDs, bool) {
+	if ids, ok := ctx.Value(idsKey{}).(IDs); ok {
+		return ids, true
+	}
+	ids, ok := ctx.Value(ginIDsKey).(IDs)
+	return ids, ok
+}
+
+// SetHeaders sets the ids of the context on the headers of an upstream request
+func SetHeaders(ctx context.Context, header http.Header) {
+	ids, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(RequestIDHeader, ids.RequestID)
+	header.Set(CorrelationIDHeader, ids.CorrelationID)
+}
+
+// Logger returns a logger that starts every line with the ids of the context, or the logger when it has none
+func Logger(ctx context.Context, logger logging.Logger) logging.Logger {
+	ids, ok := FromContext(ctx)
+	if !ok {
+		return logger
+	}
+
+	return logs.WithPrefix(logger, fmt.Sprintf("[request_id=%s correlation_id=%s] ", ids.RequestID, ids.CorrelationID))
+}
+
+// newIDs returns the ids of the values, generating the ones that are empty
+func newIDs(requestID, correlationID string) IDs {
+	if requestID == "" {
+		requestID = NewID()
+	}
+	if correlationID == "" {
+		correlationID = NewID()
+	}
+	return IDs{RequestID: requestID, CorrelationID: correlationID}
+}
@@ -0,0 +1,68 @@
+// Package correlation provides the ids that tie the logs of a request to the logs and calls of the clients it uses
+package correlation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ginIDsKey is the key of the ids in the gin context, which the handlers use as the context of their calls
+const ginIDsKey = "correlation.ids"
+
+// Middleware propagates the ids of the request headers to the context of the request and to the response headers,
+// generating the missing ones. The request id defaults to the one the go-core server assigned to the request.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetString(ginRequestIDKey)
+		if requestID == "" {
+			requestID = ctx.GetHeader(RequestIDHeader)
+		}
+		ids := newIDs(requestID, ctx.GetHeader(CorrelationIDHeader))
+
+		ctx.Set(ginIDsKey, ids)
+		ctx.Request = ctx.Request.WithContext(WithIDs(ctx.Request.Context(), ids))
+		ctx.Header(RequestIDHeader, ids.RequestID)
+		ctx.Header(CorrelationIDHeader, ids.CorrelationID)
+
+		ctx.Next()
+	}
+}
+
+// AccessLog logs each request with its ids after it is handled. The query isn't logged, it may carry secrets.
+func AccessLog(logger logging.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		Logger(ctx, logger).Infof("%s %s %d %v %s",
+			ctx.Request.Method, ctx.Request.URL.Path, ctx.Writer.Status(), time.Since(start), ctx.ClientIP())
+	}
+}
+
+// UnaryServerInterceptor propagates the ids of the request metadata to the context of the request
+// and to the response header, generating the missing ones
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ids := newIDs(firstValue(ctx, RequestIDHeader), firstValue(ctx, CorrelationIDHeader))
+
+		// the header fails only when it was already sent, which a unary handler didn't do yet
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, ids.RequestID, CorrelationIDHeader, ids.CorrelationID))
+
+		return handler(WithIDs(ctx, ids), req)
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(key))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
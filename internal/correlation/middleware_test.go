@@ -0,0 +1,109 @@
+package correlation_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+type recordingTransport struct {
+	header http.Header
+}
+
+func (t *recordingTransport) Do(req *http.Request) (*http.Response, error) {
+	t.header = req.Header.Clone()
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	serve := func(header http.Header) (*httptest.ResponseRecorder, correlation.IDs, correlation.IDs) {
+		gin.SetMode(gin.TestMode)
+		httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+		httpServer.Router().Use(correlation.Middleware())
+
+		var fromGin, fromRequest correlation.IDs
+		httpServer.Router().GET("/ids", func(ctx *gin.Context) {
+			fromGin, _ = correlation.FromContext(ctx)
+			fromRequest, _ = correlation.FromContext(ctx.Request.Context())
+		})
+
+		request := httptest.NewRequest(http.MethodGet, "/ids", nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder, request)
+		return recorder, fromGin, fromRequest
+	}
+
+	t.Run("propagates the ids of the request", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(correlation.RequestIDHeader, "request")
+		header.Set(correlation.CorrelationIDHeader, "correlation")
+
+		recorder, fromGin, fromRequest := serve(header)
+
+		expected := correlation.IDs{RequestID: "request", CorrelationID: "correlation"}
+		if diff := cmp.Diff(expected, fromGin); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(expected, fromRequest); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if recorder.Header().Get(correlation.RequestIDHeader) != "request" ||
+			recorder.Header().Get(correlation.CorrelationIDHeader) != "correlation" {
+			t.Fatalf("expected the ids in the response headers, got %v", recorder.Header())
+		}
+	})
+
+	t.Run("generates the missing ids", func(t *testing.T) {
+		recorder, fromGin, _ := serve(http.Header{})
+
+		if fromGin.RequestID == "" || fromGin.CorrelationID == "" || fromGin.RequestID == fromGin.CorrelationID {
+			t.Fatalf("expected new ids, got %+v", fromGin)
+		}
+		if recorder.Header().Get(correlation.CorrelationIDHeader) != fromGin.CorrelationID {
+			t.Fatalf("expected the correlation id in the response headers, got %v", recorder.Header())
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	transport := &recordingTransport{}
+	pipeline := runtime.NewPipeline("correlation", "v1", runtime.PipelineOptions{},
+		&policy.ClientOptions{Transport: transport, PerCallPolicies: []policy.Policy{correlation.NewPolicy()}})
+
+	ids := correlation.IDs{RequestID: "request", CorrelationID: "correlation"}
+	req, err := runtime.NewRequest(correlation.WithIDs(context.Background(), ids), http.MethodGet, "https://azure/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.header.Get(correlation.AzureRequestIDHeader) != "request" ||
+		transport.header.Get(correlation.CorrelationIDHeader) != "correlation" {
+		t.Fatalf("expected the ids in the upstream request headers, got %v", transport.header)
+	}
+}
@@ -0,0 +1,23 @@
+// Package correlation provides the ids that tie the logs of a request to the logs and calls of the clients it uses
+package correlation
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type headerPolicy struct{}
+
+// NewPolicy returns a policy of the azure pipelines that sends the ids of the context of the requests
+func NewPolicy() policy.Policy {
+	return headerPolicy{}
+}
+
+func (headerPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if ids, ok := FromContext(req.Raw().Context()); ok {
+		req.Raw().Header.Set(AzureRequestIDHeader, ids.RequestID)
+		req.Raw().Header.Set(CorrelationIDHeader, ids.CorrelationID)
+	}
+	return req.Next()
+}
@@ -0,0 +1,70 @@
+// Package credential provides the Azure credential used by the adapter to call Azure APIs
+package credential
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Type defines the kind of Azure credential
+type Type string
+
+// Supported credential types
+const (
+	// TypeDefault chains the environment, workload identity, managed identity and Azure CLI credentials
+	TypeDefault Type = "default"
+	// TypeManagedIdentity uses the system-assigned managed identity, or the user-assigned one with the client id
+	TypeManagedIdentity Type = "managed_identity"
+	// TypeWorkloadIdentity exchanges the Kubernetes service account token of the token file
+	TypeWorkloadIdentity Type = "workload_identity"
+	// TypeClientCertificate authenticates a service principal with the PEM or PKCS#12 certificate file
+	TypeClientCertificate Type = "client_certificate"
+	// TypeClientSecret authenticates a service principal with the client secret
+	TypeClientSecret Type = "client_secret"
+	// TypeAzureCLI uses the account logged in the Azure CLI, meant for local development
+	TypeAzureCLI Type = "azure_cli"
+)
+
+// Configuration represents the configuration of the Azure credential.
+// The fields that are empty fall back to the defaults of the Azure SDK, e.g. AZURE_CLIENT_ID.
+type Configuration struct {
+	Type                Type   `envconfig:"AZURE_CREDENTIAL_TYPE" default:"default" json:"type"`
+	ClientID            string `envconfig:"AZURE_CREDENTIAL_CLIENT_ID" default:"" json:"clientId"`
+	TenantID            string `envconfig:"AZURE_CREDENTIAL_TENANT_ID" default:"" json:"tenantId"`
+	ClientSecret        string `envconfig:"AZURE_CREDENTIAL_CLIENT_SECRET" default:"" json:"clientSecret"`
+	CertificatePath     string `envconfig:"AZURE_CREDENTIAL_CERTIFICATE_PATH" default:"" json:"certificatePath"`
+	CertificatePassword string `envconfig:"AZURE_CREDENTIAL_CERTIFICATE_PASSWORD" default:"" json:"certificatePassword"`
+	TokenFilePath       string `envconfig:"AZURE_CREDENTIAL_TOKEN_FILE_PATH" default:"" json:"tokenFilePath"`
+	// AuthorityHost is the Azure AD authority of sovereign clouds, e.g. https://login.microsoftonline.us/
+	AuthorityHost string `envconfig:"AZURE_CREDENTIAL_AUTHORITY_HOST" default:"" json:"authorityHost"`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// Validate checks the configuration has the fields required by the credential type
+func (c Configuration) Validate() error {
+	switch c.Type {
+	case TypeDefault, TypeManagedIdentity, TypeWorkloadIdentity, TypeAzureCLI:
+		return nil
+	case TypeClientSecret:
+		if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" {
+			return fmt.Errorf("%s credential requires a tenant id, a client id and a client secret", c.Type)
+		}
+		return nil
+	case TypeClientCertificate:
+		if c.TenantID == "" || c.ClientID == "" || c.CertificatePath == "" {
+			return fmt.Errorf("%s credential requires a tenant id, a client id and a certificate path", c.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid credential type '%s'", c.Type)
+	}
+}
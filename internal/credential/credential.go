@@ -0,0 +1,68 @@
+// Package credential provides the Azure credential used by the adapter to call Azure APIs
+package credential
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// NewCredential initializes the credential of the configured type
+func NewCredential(configuration Configuration) (azcore.TokenCredential, error) {
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	clientOptions := azcore.ClientOptions{}
+	if configuration.AuthorityHost != "" {
+		clientOptions.Cloud = cloud.Configuration{ActiveDirectoryAuthorityHost: configuration.AuthorityHost}
+	}
+
+	switch configuration.Type {
+	case TypeManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if configuration.ClientID != "" {
+			options.ID = azidentity.ClientID(configuration.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+	case TypeWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			ClientID:      configuration.ClientID,
+			TenantID:      configuration.TenantID,
+			TokenFilePath: configuration.TokenFilePath,
+		})
+	case TypeClientCertificate:
+		data, err := os.ReadFile(configuration.CertificatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		certificates, key, err := azidentity.ParseCertificates(data, []byte(configuration.CertificatePassword))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate %s. Err: %v", configuration.CertificatePath, err)
+		}
+
+		return azidentity.NewClientCertificateCredential(
+			configuration.TenantID, configuration.ClientID, certificates, key,
+			&azidentity.ClientCertificateCredentialOptions{ClientOptions: clientOptions},
+		)
+	case TypeClientSecret:
+		return azidentity.NewClientSecretCredential(
+			configuration.TenantID, configuration.ClientID, configuration.ClientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions},
+		)
+	case TypeAzureCLI:
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: configuration.TenantID,
+		})
+	default:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      configuration.TenantID,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package credential_test
+
+import (
+	"testing"
+
+	"github.com/ydataai/azure-adapter/internal/credential"
+)
+
+func TestNewCredential(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration credential.Configuration
+		valid         bool
+	}{
+		{
+			name:          "default",
+			configuration: credential.Configuration{Type: credential.TypeDefault},
+			valid:         true,
+		},
+		{
+			name:          "user-assigned managed identity",
+			configuration: credential.Configuration{Type: credential.TypeManagedIdentity, ClientID: "client"},
+			valid:         true,
+		},
+		{
+			name: "client secret",
+			configuration: credential.Configuration{
+				Type: credential.TypeClientSecret, TenantID: "tenant", ClientID: "client", ClientSecret: "secret",
+				AuthorityHost: "https://login.microsoftonline.us/",
+			},
+			valid: true,
+		},
+		{
+			name:          "client secret without secret",
+			configuration: credential.Configuration{Type: credential.TypeClientSecret, TenantID: "tenant", ClientID: "client"},
+		},
+		{
+			name: "client certificate that doesn't exist",
+			configuration: credential.Configuration{
+				Type: credential.TypeClientCertificate, TenantID: "tenant", ClientID: "client", CertificatePath: "missing.pem",
+			},
+		},
+		{
+			name:          "unknown type",
+			configuration: credential.Configuration{Type: "password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := credential.NewCredential(tt.configuration)
+			if tt.valid && (err != nil || cred == nil) {
+				t.Fatalf("should return a credential, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("should return an error")
+			}
+		})
+	}
+}
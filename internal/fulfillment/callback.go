@@ -0,0 +1,84 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// Callback defines the notification of the operations to the platform
+type Callback interface {
+	// Notify sends the operation to the platform and returns if it applied the change, Success or Failure.
+	// An error means the platform couldn't answer, and the operation should be notified again.
+	Notify(ctx context.Context, operation WebhookOperation) (string, error)
+}
+
+type httpCallback struct {
+	url     string
+	headers map[string]string
+	timeout time.Duration
+	pl      coreHTTP.Pipeline
+}
+
+// NewCallback initializes the callback of the configuration, which accepts every change when it has no URL
+func NewCallback(configuration WebhookConfiguration) Callback {
+	if configuration.CallbackURL == "" {
+		return acceptCallback{}
+	}
+
+	return httpCallback{
+		url:     configuration.CallbackURL,
+		headers: configuration.CallbackHeaders,
+		timeout: configuration.CallbackTimeout,
+		pl:      coreHTTP.NewPipeline(),
+	}
+}
+
+// Notify posts the operation as JSON, the platform accepts the change with a 2xx status code
+// and rejects it with a 409 or 422 status code. The other status codes are errors, the 4xx ones are usually
+// a misconfiguration of the callback, e.g. 401, and reporting a failure to the marketplace can't be undone.
+func (c httpCallback) Notify(ctx context.Context, operation WebhookOperation) (string, error) {
+	tCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := coreHTTP.NewRequest(tCtx, http.MethodPost, c.url)
+	if err != nil {
+		return "", err
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	correlation.SetHeaders(ctx, req.Header)
+
+	if err := req.EncodeAsJSON(operation); err != nil {
+		return "", err
+	}
+
+	resp, err := c.pl.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return OperationSuccess, nil
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusUnprocessableEntity:
+		return OperationFailure, nil
+	default:
+		return "", fmt.Errorf("request failed with error %s", resp.Status)
+	}
+}
+
+type acceptCallback struct{}
+
+func (acceptCallback) Notify(context.Context, WebhookOperation) (string, error) {
+	return OperationSuccess, nil
+}
@@ -0,0 +1,265 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	armruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+const (
+	apiVersion       = "2018-08-31"
+	subscriptionsAPI = "saas/subscriptions"
+	// audience is the resource of the tokens accepted by the marketplace API
+	audience = "20e940b3-4c77-4b0b-9a53-9e16a1b010a7"
+	// marketplaceTokenHeader carries the purchase token of the landing page
+	marketplaceTokenHeader  = "x-ms-marketplace-token"
+	operationLocationHeader = "Operation-Location"
+)
+
+// Client defines an interface for the SaaS fulfillment client
+type Client interface {
+	// Resolve returns the subscription of the purchase token the landing page received
+	Resolve(ctx context.Context, token string) (ResolvedSubscription, error)
+	// Activate starts the billing of a subscription
+	Activate(ctx context.Context, subscriptionID string, request ActivateRequest) error
+	// Get returns a subscription
+	Get(ctx context.Context, subscriptionID string) (Subscription, error)
+	// List returns every subscription of the publisher, following the pages
+	List(ctx context.Context) ([]Subscription, error)
+	// ListAvailablePlans returns the plans the subscription can change to
+	ListAvailablePlans(ctx context.Context, subscriptionID string) ([]Plan, error)
+	// Update changes the plan or the quantity of a subscription, returning the operation that applies it
+	Update(ctx context.Context, subscriptionID string, request UpdateRequest) (Operation, error)
+	// Delete unsubscribes a subscription, returning the operation that applies it
+	Delete(ctx context.Context, subscriptionID string) (Operation, error)
+	// UpdateOperation reports if the change of an operation the webhook received was applied or not
+	UpdateOperation(ctx context.Context, subscriptionID string, operationID string, status string) error
+}
+
+// TokenScope is the scope of the tokens of the marketplace API, which readiness checks use to verify it works
+const TokenScope = audience + "/.default"
+
+type fulfillmentClient struct {
+	configuration Configuration
+	logger        logging.Logger
+	pl            runtime.Pipeline
+}
+
+// NewClient initializes the fulfillment client
+func NewClient(credential azcore.TokenCredential, configuration Configuration, logger logging.Logger) (Client, error) {
+	return newClient(credential, configuration, logger, nil)
+}
+
+func newClient(
+	credential azcore.TokenCredential, configuration Configuration, logger logging.Logger, transport policy.Transporter,
+) (Client, error) {
+	pl, err := armruntime.NewPipeline("fulfillment", "v0.1.0", credential, runtime.PipelineOptions{}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: cloud.Configuration{
+				Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {Endpoint: configuration.Endpoint, Audience: audience},
+				},
+			},
+			Transport:       transport,
+			PerCallPolicies: []policy.Policy{correlation.NewPolicy()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fulfillmentClient{
+		configuration: configuration,
+		logger:        logger,
+		pl:            pl,
+	}, nil
+}
+
+// Resolve returns the subscription of the purchase token the landing page received
+func (c fulfillmentClient) Resolve(ctx context.Context, token string) (ResolvedSubscription, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "resolve")
+	if err != nil {
+		return ResolvedSubscription{}, err
+	}
+	req.Raw().Header.Set(marketplaceTokenHeader, token)
+
+	resolved := ResolvedSubscription{}
+	return resolved, c.do(req, &resolved, http.StatusOK)
+}
+
+// Activate starts the billing of a subscription
+func (c fulfillmentClient) Activate(ctx context.Context, subscriptionID string, request ActivateRequest) error {
+	correlation.Logger(ctx, c.logger).Infof("activating subscription %s with plan %s", subscriptionID, request.PlanID)
+
+	req, err := c.newRequest(ctx, http.MethodPost, url.PathEscape(subscriptionID), "activate")
+	if err != nil {
+		return err
+	}
+	if err := runtime.MarshalAsJSON(req, request); err != nil {
+		return err
+	}
+
+	return c.do(req, nil, http.StatusOK)
+}
+
+// Get returns a subscription
+func (c fulfillmentClient) Get(ctx context.Context, subscriptionID string) (Subscription, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, url.PathEscape(subscriptionID))
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	subscription := Subscription{}
+	return subscription, c.do(req, &subscription, http.StatusOK)
+}
+
+// List returns every subscription of the publisher, following the pages
+func (c fulfillmentClient) List(ctx context.Context) ([]Subscription, error) {
+	req, err := c.newRequest(ctx, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := []Subscription{}
+	for {
+		page := subscriptionList{}
+		if err := c.do(req, &page, http.StatusOK); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, page.Subscriptions...)
+
+		if page.NextLink == "" {
+			return subscriptions, nil
+		}
+
+		// the next link already has the query of the page, including the api version
+		if req, err = runtime.NewRequest(ctx, http.MethodGet, page.NextLink); err != nil {
+			return nil, err
+		}
+		req.Raw().Header.Set("Accept", "application/json")
+	}
+}
+
+// ListAvailablePlans returns the plans the subscription can change to
+func (c fulfillmentClient) ListAvailablePlans(ctx context.Context, subscriptionID string) ([]Plan, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, url.PathEscape(subscriptionID), "listAvailablePlans")
+	if err != nil {
+		return nil, err
+	}
+
+	plans := planList{}
+	return plans.Plans, c.do(req, &plans, http.StatusOK)
+}
+
+// Update changes the plan or the quantity of a subscription, returning the operation that applies it
+func (c fulfillmentClient) Update(ctx context.Context, subscriptionID string, request UpdateRequest) (Operation, error) {
+	if err := request.validate(); err != nil {
+		return Operation{}, err
+	}
+
+	correlation.Logger(ctx, c.logger).Infof("updating subscription %s with %+v", subscriptionID, request)
+
+	req, err := c.newRequest(ctx, http.MethodPatch, url.PathEscape(subscriptionID))
+	if err != nil {
+		return Operation{}, err
+	}
+	if err := runtime.MarshalAsJSON(req, request); err != nil {
+		return Operation{}, err
+	}
+
+	return c.doOperation(req)
+}
+
+// Delete unsubscribes a subscription, returning the operation that applies it
+func (c fulfillmentClient) Delete(ctx context.Context, subscriptionID string) (Operation, error) {
+	correlation.Logger(ctx, c.logger).Infof("deleting subscription %s", subscriptionID)
+
+	req, err := c.newRequest(ctx, http.MethodDelete, url.PathEscape(subscriptionID))
+	if err != nil {
+		return Operation{}, err
+	}
+
+	return c.doOperation(req)
+}
+
+// UpdateOperation reports if the change of an operation the webhook received was applied or not
+func (c fulfillmentClient) UpdateOperation(
+	ctx context.Context, subscriptionID string, operationID string, status string,
+) error {
+	correlation.Logger(ctx, c.logger).Infof("updating operation %s of subscription %s with status %s",
+		operationID, subscriptionID, status)
+
+	req, err := c.newRequest(ctx, http.MethodPatch,
+		url.PathEscape(subscriptionID), "operations", url.PathEscape(operationID))
+	if err != nil {
+		return err
+	}
+	if err := runtime.MarshalAsJSON(req, operationUpdate{Status: status}); err != nil {
+		return err
+	}
+
+	return c.do(req, nil, http.StatusOK)
+}
+
+func (c fulfillmentClient) newRequest(ctx context.Context, method string, paths ...string) (*policy.Request, error) {
+	req, err := runtime.NewRequest(ctx, method,
+		runtime.JoinPaths(c.configuration.Endpoint, append([]string{subscriptionsAPI}, paths...)...))
+	if err != nil {
+		return nil, err
+	}
+
+	reqQP := req.Raw().URL.Query()
+	reqQP.Set("api-version", apiVersion)
+	req.Raw().URL.RawQuery = reqQP.Encode()
+	req.Raw().Header.Set("Accept", "application/json")
+
+	return req, nil
+}
+
+// do sends the request and decodes the JSON body into the result, when there is one
+func (c fulfillmentClient) do(req *policy.Request, result any, statusCodes ...int) error {
+	resp, err := c.pl.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if !runtime.HasStatusCode(resp, statusCodes...) {
+		return runtime.NewResponseError(resp)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return runtime.UnmarshalAsJSON(resp, result)
+}
+
+// doOperation sends the request of an asynchronous change and returns its operation
+func (c fulfillmentClient) doOperation(req *policy.Request) (Operation, error) {
+	resp, err := c.pl.Do(req)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	if !runtime.HasStatusCode(resp, http.StatusOK, http.StatusAccepted) {
+		return Operation{}, runtime.NewResponseError(resp)
+	}
+
+	operation := Operation{Location: resp.Header.Get(operationLocationHeader)}
+	if location, err := url.Parse(operation.Location); err == nil && operation.Location != "" {
+		operation.ID = path.Base(location.Path)
+	}
+
+	return operation, nil
+}
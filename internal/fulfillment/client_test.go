@@ -0,0 +1,121 @@
+package fulfillment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestClient(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+	subscriptionID := "37f9dea2-4345-438f-b0bd-03d40d28c7e0"
+
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	respond := func(w http.ResponseWriter, value any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(value)
+	}
+
+	mux.HandleFunc("POST /api/saas/subscriptions/resolve", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(marketplaceTokenHeader) != "purchase-token" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		respond(w, ResolvedSubscription{ID: subscriptionID, PlanID: "gold"})
+	})
+	mux.HandleFunc("GET /api/saas/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			respond(w, subscriptionList{
+				Subscriptions: []Subscription{{ID: "first"}},
+				NextLink:      server.URL + "/api/saas/subscriptions?continuationToken=next&api-version=" + apiVersion,
+			})
+			return
+		}
+		respond(w, subscriptionList{Subscriptions: []Subscription{{ID: "second"}}})
+	})
+	mux.HandleFunc("PATCH /api/saas/subscriptions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(operationLocationHeader,
+			server.URL+"/api/saas/subscriptions/"+r.PathValue("id")+"/operations/operation-id?api-version="+apiVersion)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /api/saas/subscriptions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client, err := newClient(fakeCredential{}, Configuration{Endpoint: server.URL + "/api"}, logger, server.Client())
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("resolve", func(t *testing.T) {
+		resolved, err := client.Resolve(ctx, "purchase-token")
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+		if resolved.ID != subscriptionID || resolved.PlanID != "gold" {
+			t.Fatalf("unexpected subscription %+v", resolved)
+		}
+	})
+
+	t.Run("list follows the pages", func(t *testing.T) {
+		subscriptions, err := client.List(ctx)
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		ids := []string{}
+		for _, subscription := range subscriptions {
+			ids = append(ids, subscription.ID)
+		}
+		if diff := cmp.Diff([]string{"first", "second"}, ids); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("update returns the operation", func(t *testing.T) {
+		operation, err := client.Update(ctx, subscriptionID, UpdateRequest{PlanID: "platinum"})
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+		if operation.ID != "operation-id" {
+			t.Fatalf("expected the id of the operation, got %+v", operation)
+		}
+
+		if _, err := client.Update(ctx, subscriptionID, UpdateRequest{PlanID: "platinum", Quantity: 2}); err == nil {
+			t.Fatal("expected an error when changing the plan and the quantity at once")
+		}
+	})
+
+	t.Run("errors keep the status of azure", func(t *testing.T) {
+		_, err := client.Get(ctx, subscriptionID)
+		if code := errorStatusCode(err); code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d (%v)", http.StatusNotFound, code, err)
+		}
+	})
+}
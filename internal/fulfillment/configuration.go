@@ -0,0 +1,17 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Configuration represents the configuration of the fulfillment client.
+type Configuration struct {
+	// Endpoint of the marketplace API, which can point to a mock of the API while developing the landing page
+	Endpoint string `envconfig:"FULFILLMENT_ENDPOINT" default:"https://marketplaceapi.microsoft.com/api"`
+}
+
+// LoadFromEnvVars reads all env vars required for the fulfillment client.
+func (c *Configuration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
@@ -0,0 +1,272 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+)
+
+// RESTController defines the rest controller of the SaaS subscriptions
+type RESTController struct {
+	logger        logging.Logger
+	configuration config.RESTControllerConfiguration
+	client        Client
+	guard         auth.Guard
+}
+
+// NewRESTController initializes the rest controller
+func NewRESTController(
+	logger logging.Logger,
+	client Client,
+	guard auth.Guard,
+	configuration config.RESTControllerConfiguration,
+) RESTController {
+	return RESTController{
+		logger:        logger,
+		configuration: configuration,
+		client:        client,
+		guard:         guard,
+	}
+}
+
+// Boot ...
+func (r RESTController) Boot(s server.Server) {
+	read := s.Router().Group("/fulfillment/subscriptions", r.guard.Require(auth.ScopeFulfillmentRead))
+	read.GET("", r.list())
+	read.GET("/:subscriptionId", r.get())
+	read.GET("/:subscriptionId/plans", r.listAvailablePlans())
+
+	write := s.Router().Group("/fulfillment/subscriptions", r.guard.Require(auth.ScopeFulfillmentWrite))
+	write.POST("/resolve", r.resolve())
+	write.POST("/:subscriptionId/activate", r.activate())
+	write.PATCH("/:subscriptionId", r.update())
+	write.DELETE("/:subscriptionId", r.delete())
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r RESTController) Describe(spec *openapi.Spec) {
+	subscriptionID := openapi3.Parameters{{Value: openapi3.NewPathParameter("subscriptionId").
+		WithDescription("Id of the SaaS subscription").
+		WithSchema(openapi3.NewUUIDSchema())}}
+	subscription := spec.Schema(Subscription{}, "id", "planId", "saasSubscriptionStatus")
+	operation := spec.Schema(Operation{}, "operationId", "operationLocation")
+
+	resolve := spec.Schema(ResolveRequest{}, "token")
+	resolve.Value.Properties["token"] = openapi3.NewStringSchema().WithMinLength(1).NewRef()
+
+	activate := spec.Schema(ActivateRequest{}, "planId")
+	activate.Value.Properties["planId"] = openapi3.NewStringSchema().WithMinLength(1).NewRef()
+
+	update := spec.Schema(UpdateRequest{})
+	update.Value.AdditionalProperties = openapi3.AdditionalProperties{Has: openapi3.BoolPtr(false)}
+
+	spec.AddOperation(http.MethodGet, "/fulfillment/subscriptions", openapi.Operation{
+		ID:        "listSubscriptions",
+		Summary:   "Lists the SaaS subscriptions of the publisher",
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: openapi3.NewArraySchema().WithItems(subscription.Value).NewRef()},
+	})
+
+	spec.AddOperation(http.MethodGet, "/fulfillment/subscriptions/:subscriptionId", openapi.Operation{
+		ID:         "getSubscription",
+		Summary:    "Returns a SaaS subscription",
+		Parameters: subscriptionID,
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: subscription},
+	})
+
+	spec.AddOperation(http.MethodGet, "/fulfillment/subscriptions/:subscriptionId/plans", openapi.Operation{
+		ID:         "listAvailablePlans",
+		Summary:    "Lists the plans a SaaS subscription can change to",
+		Parameters: subscriptionID,
+		Responses: map[int]*openapi3.SchemaRef{
+			http.StatusOK: openapi3.NewArraySchema().WithItems(spec.Schema(Plan{}, "planId").Value).NewRef(),
+		},
+	})
+
+	spec.AddOperation(http.MethodPost, "/fulfillment/subscriptions/resolve", openapi.Operation{
+		ID:        "resolveSubscription",
+		Summary:   "Resolves the purchase token of the landing page into its SaaS subscription",
+		Request:   resolve,
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(ResolvedSubscription{}, "id", "planId")},
+	})
+
+	spec.AddOperation(http.MethodPost, "/fulfillment/subscriptions/:subscriptionId/activate", openapi.Operation{
+		ID:         "activateSubscription",
+		Summary:    "Activates a SaaS subscription, which starts its billing",
+		Parameters: subscriptionID,
+		Request:    activate,
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusNoContent: nil},
+	})
+
+	spec.AddOperation(http.MethodPatch, "/fulfillment/subscriptions/:subscriptionId", openapi.Operation{
+		ID:         "updateSubscription",
+		Summary:    "Changes the plan or the quantity of a SaaS subscription",
+		Parameters: subscriptionID,
+		Request:    update,
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusAccepted: operation},
+	})
+
+	spec.AddOperation(http.MethodDelete, "/fulfillment/subscriptions/:subscriptionId", openapi.Operation{
+		ID:         "deleteSubscription",
+		Summary:    "Unsubscribes a SaaS subscription",
+		Parameters: subscriptionID,
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusAccepted: operation},
+	})
+}
+
+func (r RESTController) list() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		subscriptions, err := r.client.List(tCtx)
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, subscriptions)
+	}
+}
+
+func (r RESTController) get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		subscription, err := r.client.Get(tCtx, ctx.Param("subscriptionId"))
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, subscription)
+	}
+}
+
+func (r RESTController) listAvailablePlans() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		plans, err := r.client.ListAvailablePlans(tCtx, ctx.Param("subscriptionId"))
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, plans)
+	}
+}
+
+func (r RESTController) resolve() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		request := ResolveRequest{}
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		resolved, err := r.client.Resolve(tCtx, request.Token)
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, resolved)
+	}
+}
+
+func (r RESTController) activate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		request := ActivateRequest{}
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		if err := r.client.Activate(tCtx, ctx.Param("subscriptionId"), request); err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func (r RESTController) update() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		request := UpdateRequest{}
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		operation, err := r.client.Update(tCtx, ctx.Param("subscriptionId"), request)
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, operation)
+	}
+}
+
+func (r RESTController) delete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		operation, err := r.client.Delete(tCtx, ctx.Param("subscriptionId"))
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, operation)
+	}
+}
+
+// failed responds with the status of azure when the request was invalid, or a bad gateway otherwise,
+// since the other errors, e.g. an unauthorized token, are of the adapter and not of the caller
+func (r RESTController) failed(ctx *gin.Context, err error) {
+	correlation.Logger(ctx, r.logger).Errorf("failed with error %v", err)
+
+	ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
+}
+
+func errorStatusCode(err error) int {
+	if errors.Is(err, errInvalidUpdate) {
+		return http.StatusBadRequest
+	}
+
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		switch responseErr.StatusCode {
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict:
+			return responseErr.StatusCode
+		}
+	}
+
+	return http.StatusBadGateway
+}
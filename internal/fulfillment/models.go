@@ -0,0 +1,163 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"errors"
+	"time"
+)
+
+// Subscription statuses of the SaaS fulfillment API
+const (
+	StatusPendingFulfillmentStart = "PendingFulfillmentStart"
+	StatusSubscribed              = "Subscribed"
+	StatusSuspended               = "Suspended"
+	StatusUnsubscribed            = "Unsubscribed"
+)
+
+// Actions of the operations the marketplace notifies the webhook of
+const (
+	ActionChangePlan     = "ChangePlan"
+	ActionChangeQuantity = "ChangeQuantity"
+	ActionSuspend        = "Suspend"
+	ActionReinstate      = "Reinstate"
+	ActionRenew          = "Renew"
+	ActionUnsubscribe    = "Unsubscribe"
+)
+
+// Statuses of the operations, the webhook reports the result of the changes with Success or Failure
+const (
+	OperationInProgress = "InProgress"
+	OperationSuccess    = "Success"
+	OperationFailure    = "Failure"
+)
+
+// Subscription represents a SaaS subscription of the marketplace
+type Subscription struct {
+	ID                        string    `json:"id"`
+	PublisherID               string    `json:"publisherId,omitempty"`
+	OfferID                   string    `json:"offerId"`
+	Name                      string    `json:"name"`
+	SaasSubscriptionStatus    string    `json:"saasSubscriptionStatus"`
+	Beneficiary               User      `json:"beneficiary"`
+	Purchaser                 User      `json:"purchaser"`
+	PlanID                    string    `json:"planId"`
+	Quantity                  int       `json:"quantity,omitempty"`
+	Term                      Term      `json:"term"`
+	AutoRenew                 bool      `json:"autoRenew"`
+	IsTest                    bool      `json:"isTest"`
+	IsFreeTrial               bool      `json:"isFreeTrial"`
+	AllowedCustomerOperations []string  `json:"allowedCustomerOperations,omitempty"`
+	SessionMode               string    `json:"sessionMode,omitempty"`
+	SandboxType               string    `json:"sandboxType,omitempty"`
+	Created                   time.Time `json:"created,omitempty"`
+	LastModified              time.Time `json:"lastModified,omitempty"`
+}
+
+// User represents the beneficiary or the purchaser of a subscription
+type User struct {
+	EmailID  string `json:"emailId,omitempty"`
+	ObjectID string `json:"objectId,omitempty"`
+	TenantID string `json:"tenantId,omitempty"`
+	PUID     string `json:"puid,omitempty"`
+}
+
+// Term represents the current billing term of a subscription
+type Term struct {
+	StartDate time.Time `json:"startDate,omitempty"`
+	EndDate   time.Time `json:"endDate,omitempty"`
+	TermUnit  string    `json:"termUnit,omitempty"`
+}
+
+// ResolvedSubscription represents the subscription identified by a purchase token of the landing page
+type ResolvedSubscription struct {
+	ID               string       `json:"id"`
+	SubscriptionName string       `json:"subscriptionName"`
+	OfferID          string       `json:"offerId"`
+	PlanID           string       `json:"planId"`
+	Quantity         int          `json:"quantity,omitempty"`
+	Subscription     Subscription `json:"subscription"`
+}
+
+// Plan represents a plan a subscription can change to
+type Plan struct {
+	PlanID         string `json:"planId"`
+	DisplayName    string `json:"displayName"`
+	IsPrivate      bool   `json:"isPrivate"`
+	Description    string `json:"description,omitempty"`
+	MinQuantity    int    `json:"minQuantity,omitempty"`
+	MaxQuantity    int    `json:"maxQuantity,omitempty"`
+	HasFreeTrials  bool   `json:"hasFreeTrials,omitempty"`
+	IsPricePerSeat bool   `json:"isPricePerSeat,omitempty"`
+}
+
+// ActivateRequest represents the plan and the quantity a subscription is activated with
+type ActivateRequest struct {
+	PlanID   string `json:"planId" binding:"required"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// UpdateRequest represents a change of the plan or of the quantity of a subscription, only one at a time
+type UpdateRequest struct {
+	PlanID   string `json:"planId,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+var errInvalidUpdate = errors.New("the update must change either the plan or the quantity")
+
+func (r UpdateRequest) validate() error {
+	if (r.PlanID == "") == (r.Quantity == 0) {
+		return errInvalidUpdate
+	}
+	return nil
+}
+
+// Operation represents the asynchronous operation started by a change of a subscription
+type Operation struct {
+	// ID of the operation, to follow it with the operations API
+	ID string `json:"operationId"`
+	// Location is the URL of the operation returned by azure
+	Location string `json:"operationLocation"`
+}
+
+// WebhookOperation represents an operation the marketplace notifies the webhook of
+type WebhookOperation struct {
+	ID                     string    `json:"id" binding:"required"`
+	ActivityID             string    `json:"activityId"`
+	PublisherID            string    `json:"publisherId,omitempty"`
+	OfferID                string    `json:"offerId"`
+	PlanID                 string    `json:"planId"`
+	Quantity               int       `json:"quantity,omitempty"`
+	SubscriptionID         string    `json:"subscriptionId" binding:"required"`
+	TimeStamp              time.Time `json:"timeStamp"`
+	Action                 string    `json:"action" binding:"required"`
+	Status                 string    `json:"status"`
+	OperationRequestSource string    `json:"operationRequestSource,omitempty"`
+}
+
+// requiresUpdate returns true when the marketplace waits for the result of the change,
+// the other actions were already applied and only notify the publisher
+func (o WebhookOperation) requiresUpdate() bool {
+	switch o.Action {
+	case ActionChangePlan, ActionChangeQuantity, ActionReinstate:
+		return o.Status == OperationInProgress
+	}
+	return false
+}
+
+// ResolveRequest represents the purchase token of the landing page
+type ResolveRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type subscriptionList struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	NextLink      string         `json:"@nextLink"`
+}
+
+type planList struct {
+	Plans []Plan `json:"plans"`
+}
+
+type operationUpdate struct {
+	Status string `json:"status"`
+}
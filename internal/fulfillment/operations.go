@@ -0,0 +1,125 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// OperationRecord represents the processing of an operation the webhook received
+type OperationRecord struct {
+	Operation WebhookOperation `json:"operation"`
+	// Result is the answer of the platform to the change, Success or Failure, empty until the platform answers
+	Result string `json:"result,omitempty"`
+	// Completed is true once the platform answered and, when required, the marketplace was updated with the result
+	Completed  bool      `json:"completed"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// OperationStore defines the store of the operations the webhook received,
+// which lets the retries of the marketplace resume the processing of an operation
+type OperationStore interface {
+	// Get returns the record of the operation, if it was received
+	Get(operationID string) (OperationRecord, bool)
+	// Save creates or replaces the record of an operation
+	Save(record OperationRecord) error
+	Close() error
+}
+
+type operationStore struct {
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]OperationRecord
+}
+
+// NewOperationStore initializes a store of the operations.
+// When the path isn't empty every change is appended to the file, and the records already there are loaded.
+func NewOperationStore(path string) (OperationStore, error) {
+	s := &operationStore{records: map[string]OperationRecord{}}
+
+	if path == "" {
+		return s, nil
+	}
+
+	if err := s.load(path); err != nil {
+		return nil, fmt.Errorf("could not load operations %s. Err: %v", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+
+	return s, nil
+}
+
+// Get returns the record of the operation, if it was received
+func (s *operationStore) Get(operationID string) (OperationRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[operationID]
+	return record, ok
+}
+
+// Save creates or replaces the record of an operation, persisting it first when the store has a file
+func (s *operationStore) Save(record OperationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if err := json.NewEncoder(s.file).Encode(record); err != nil {
+			return err
+		}
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	s.records[record.Operation.ID] = record
+	return nil
+}
+
+func (s *operationStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+// load reads the records of the file, the last line of an operation is its latest record
+func (s *operationStore) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := OperationRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		s.records[record.Operation.ID] = record
+	}
+
+	return scanner.Err()
+}
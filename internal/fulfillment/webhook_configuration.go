@@ -0,0 +1,57 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+)
+
+// WebhookConfiguration represents the configuration of the webhook the marketplace notifies the operations to.
+// The webhook is enabled when the audience is configured.
+type WebhookConfiguration struct {
+	// Audience is the id of the Entra application configured in the technical configuration of the offer
+	Audience string `envconfig:"FULFILLMENT_WEBHOOK_AUDIENCE" default:""`
+	// Issuer of the tokens, e.g. https://sts.windows.net/<tenant id>/, any when empty
+	Issuer      string        `envconfig:"FULFILLMENT_WEBHOOK_ISSUER" default:""`
+	JWKSURL     string        `envconfig:"FULFILLMENT_WEBHOOK_JWKS_URL" default:"https://login.microsoftonline.com/common/discovery/v2.0/keys"`
+	JWKSRefresh time.Duration `envconfig:"FULFILLMENT_WEBHOOK_JWKS_REFRESH" default:"1h"`
+	JWKSTimeout time.Duration `envconfig:"FULFILLMENT_WEBHOOK_JWKS_TIMEOUT" default:"10s"`
+	Leeway      time.Duration `envconfig:"FULFILLMENT_WEBHOOK_LEEWAY" default:"1m"`
+
+	// OperationsPath is the file the received operations are kept in, in memory when empty
+	OperationsPath string `envconfig:"FULFILLMENT_WEBHOOK_OPERATIONS_PATH" default:""`
+
+	// CallbackURL of the platform, which is posted the operations and accepts the changes with a 2xx status code
+	// or rejects them with a 409 or 422 status code. Every change is accepted when empty.
+	CallbackURL     string            `envconfig:"FULFILLMENT_WEBHOOK_CALLBACK_URL" default:""`
+	CallbackHeaders map[string]string `envconfig:"FULFILLMENT_WEBHOOK_CALLBACK_HEADERS" default:""`
+	CallbackTimeout time.Duration     `envconfig:"FULFILLMENT_WEBHOOK_CALLBACK_TIMEOUT" default:"10s"`
+}
+
+// LoadFromEnvVars reads all env vars required for the fulfillment webhook.
+func (c *WebhookConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
+
+// Enabled returns true when the webhook is configured
+func (c WebhookConfiguration) Enabled() bool {
+	return c.Audience != ""
+}
+
+// Authentication returns the configuration of the tokens of the marketplace, which are issued to its application
+func (c WebhookConfiguration) Authentication() auth.Configuration {
+	return auth.Configuration{
+		Methods:     []auth.Method{auth.MethodJWT},
+		JWKSURL:     c.JWKSURL,
+		JWKSRefresh: c.JWKSRefresh,
+		Issuer:      c.Issuer,
+		Audience:    c.Audience,
+		Leeway:      c.Leeway,
+		HTTPTimeout: c.JWKSTimeout,
+		// the marketplace calls the webhook with the application that is also the resource of its API
+		AllowedApplications: []string{audience},
+	}
+}
@@ -0,0 +1,186 @@
+// Package fulfillment provides objects to interact with the SaaS fulfillment API v2 of the marketplace
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+)
+
+// WebhookController defines the rest controller of the webhook the marketplace notifies the operations to
+type WebhookController struct {
+	logger        logging.Logger
+	configuration config.RESTControllerConfiguration
+	client        Client
+	store         OperationStore
+	callback      Callback
+	plans         metering.PlanRecorder
+	guard         auth.Guard
+	// mu guards the operations in progress, so the retries of the marketplace don't process one twice.
+	// It isn't held while the operations notify the platform and update the marketplace.
+	mu         *sync.Mutex
+	inProgress map[string]bool
+}
+
+// errOperationInProgress is returned when the marketplace retries an operation that is still being processed
+var errOperationInProgress = errors.New("the operation is being processed")
+
+// NewWebhookController initializes the webhook controller, the guard authenticates the tokens of the marketplace.
+// The plans record the changes of plan the platform applied, when they aren't nil.
+func NewWebhookController(
+	logger logging.Logger,
+	client Client,
+	store OperationStore,
+	callback Callback,
+	plans metering.PlanRecorder,
+	guard auth.Guard,
+	configuration config.RESTControllerConfiguration,
+) WebhookController {
+	return WebhookController{
+		logger:        logger,
+		configuration: configuration,
+		client:        client,
+		store:         store,
+		callback:      callback,
+		plans:         plans,
+		guard:         guard,
+		mu:            &sync.Mutex{},
+		inProgress:    map[string]bool{},
+	}
+}
+
+// Boot ...
+func (r WebhookController) Boot(s server.Server) {
+	s.Router().POST("/fulfillment/webhook", r.guard.Require(), r.receive())
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r WebhookController) Describe(spec *openapi.Spec) {
+	operation := spec.Schema(WebhookOperation{}, "id", "subscriptionId", "action")
+	// the marketplace sends a null quantity for the plans that aren't billed per seat
+	operation.Value.Properties["quantity"] = openapi3.NewIntegerSchema().WithNullable().NewRef()
+
+	spec.AddOperation(http.MethodPost, "/fulfillment/webhook", openapi.Operation{
+		ID:        "receiveOperation",
+		Summary:   "Receives an operation of a SaaS subscription from the marketplace",
+		Request:   operation,
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: nil},
+	})
+}
+
+func (r WebhookController) receive() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		operation := WebhookOperation{}
+		if err := ctx.ShouldBindJSON(&operation); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		if err := r.process(tCtx, operation); err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("failed to process operation %s of subscription %s with error %v",
+				operation.ID, operation.SubscriptionID, err)
+			// the marketplace retries the operations the webhook doesn't accept
+			status := http.StatusInternalServerError
+			if errors.Is(err, errOperationInProgress) {
+				status = http.StatusConflict
+			}
+			ctx.JSON(status, gin.H{"message": err.Error()})
+			return
+		}
+
+		ctx.Status(http.StatusOK)
+	}
+}
+
+// process resumes the operation from its record: it notifies the platform until it answers,
+// then updates the marketplace with the result when the marketplace waits for it
+func (r WebhookController) process(ctx context.Context, operation WebhookOperation) error {
+	if !r.claim(operation.ID) {
+		return errOperationInProgress
+	}
+	defer r.release(operation.ID)
+
+	record, ok := r.store.Get(operation.ID)
+	if !ok {
+		correlation.Logger(ctx, r.logger).Infof("received operation %s %s of subscription %s",
+			operation.Action, operation.ID, operation.SubscriptionID)
+
+		record = OperationRecord{Operation: operation, ReceivedAt: time.Now(), UpdatedAt: time.Now()}
+		if err := r.store.Save(record); err != nil {
+			return err
+		}
+	}
+
+	if record.Completed {
+		return nil
+	}
+
+	if record.Result == "" {
+		result, err := r.callback.Notify(ctx, record.Operation)
+		if err != nil {
+			return err
+		}
+
+		record.Result = result
+		record.UpdatedAt = time.Now()
+		if err := r.store.Save(record); err != nil {
+			return err
+		}
+	}
+
+	if record.Operation.requiresUpdate() {
+		if err := r.client.UpdateOperation(ctx,
+			record.Operation.SubscriptionID, record.Operation.ID, record.Result); err != nil {
+			return err
+		}
+	}
+
+	if r.plans != nil && record.Operation.Action == ActionChangePlan && record.Result == OperationSuccess {
+		from := record.Operation.TimeStamp
+		if from.IsZero() {
+			from = record.ReceivedAt
+		}
+		if err := r.plans.RecordPlan(record.Operation.SubscriptionID, record.Operation.PlanID, from); err != nil {
+			return err
+		}
+	}
+
+	record.Completed = true
+	record.UpdatedAt = time.Now()
+	return r.store.Save(record)
+}
+
+// claim marks the operation in progress, it returns false when it already is
+func (r WebhookController) claim(operationID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.inProgress[operationID] {
+		return false
+	}
+	r.inProgress[operationID] = true
+	return true
+}
+
+func (r WebhookController) release(operationID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.inProgress, operationID)
+}
@@ -0,0 +1,197 @@
+package fulfillment_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/fulfillment"
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// operationsClient records the operations the webhook updates
+type operationsClient struct {
+	fulfillment.Client
+	updates []string
+}
+
+func (c *operationsClient) UpdateOperation(_ context.Context, _ string, operationID string, status string) error {
+	c.updates = append(c.updates, operationID+":"+status)
+	return nil
+}
+
+func TestWebhook(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	// the platform rejects the changes of quantity, refuses the forbidden operation and fails until it is available
+	available := false
+	notified := []string{}
+	// the platform answers the slow operation once it is released
+	started, released := make(chan struct{}), make(chan struct{})
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := fulfillment.WebhookOperation{}
+		_ = json.NewDecoder(r.Body).Decode(&operation)
+		if operation.ID == "slow" {
+			close(started)
+			<-released
+			return
+		}
+		notified = append(notified, operation.ID)
+
+		switch {
+		case !available:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case operation.ID == "forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case operation.Action == fulfillment.ActionChangeQuantity:
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer platform.Close()
+
+	path := filepath.Join(t.TempDir(), "operations.ndjson")
+	store, err := fulfillment.NewOperationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plans, err := metering.NewPlanHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &operationsClient{}
+	callback := fulfillment.NewCallback(fulfillment.WebhookConfiguration{
+		CallbackURL: platform.URL, CallbackTimeout: time.Second,
+	})
+
+	gin.SetMode(gin.TestMode)
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	fulfillment.NewWebhookController(logger, client, store, callback, plans, auth.NewGuard(logger, nil),
+		config.RESTControllerConfiguration{HTTPRequestTimeout: time.Second}).Boot(httpServer)
+
+	changedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	receive := func(id string, action string, status string) int {
+		body, _ := json.Marshal(fulfillment.WebhookOperation{
+			ID: id, SubscriptionID: "subscription", PlanID: "platinum", Action: action, Status: status, TimeStamp: changedAt,
+		})
+
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodPost, "/fulfillment/webhook", bytes.NewReader(body)))
+		return recorder.Code
+	}
+
+	t.Run("keeps the operation when the platform is unavailable", func(t *testing.T) {
+		if code := receive("change-plan", fulfillment.ActionChangePlan, fulfillment.OperationInProgress); code != http.StatusInternalServerError {
+			t.Fatalf("expected %d, got %d", http.StatusInternalServerError, code)
+		}
+
+		record, ok := store.Get("change-plan")
+		if !ok || record.Completed || len(client.updates) > 0 {
+			t.Fatalf("expected an incomplete record without updates, got %+v %v", record, client.updates)
+		}
+	})
+
+	t.Run("updates the operations with the answer of the platform", func(t *testing.T) {
+		available = true
+
+		for _, operation := range []struct{ id, action, status string }{
+			{"change-plan", fulfillment.ActionChangePlan, fulfillment.OperationInProgress},
+			{"change-quantity", fulfillment.ActionChangeQuantity, fulfillment.OperationInProgress},
+			{"unsubscribe", fulfillment.ActionUnsubscribe, "Succeeded"},
+		} {
+			if code := receive(operation.id, operation.action, operation.status); code != http.StatusOK {
+				t.Fatalf("expected %d for %s, got %d", http.StatusOK, operation.id, code)
+			}
+		}
+
+		expected := []string{"change-plan:Success", "change-quantity:Failure"}
+		if diff := cmp.Diff(expected, client.updates); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		if planID, _ := plans.PlanID("subscription", changedAt.Add(time.Hour)); planID != "platinum" {
+			t.Fatalf("expected the new plan to be recorded, got '%s'", planID)
+		}
+		if _, ok := plans.PlanID("subscription", changedAt.Add(-time.Hour)); ok {
+			t.Fatal("expected no plan before the change")
+		}
+	})
+
+	t.Run("ignores the retries of completed operations", func(t *testing.T) {
+		notifications := len(notified)
+		if code := receive("change-plan", fulfillment.ActionChangePlan, fulfillment.OperationInProgress); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+		if len(notified) != notifications || len(client.updates) != 2 {
+			t.Fatalf("expected no notification nor update, got %v %v", notified, client.updates)
+		}
+	})
+
+	t.Run("keeps the operation when the platform refuses the callback", func(t *testing.T) {
+		if code := receive("forbidden", fulfillment.ActionChangePlan, fulfillment.OperationInProgress); code != http.StatusInternalServerError {
+			t.Fatalf("expected %d, got %d", http.StatusInternalServerError, code)
+		}
+
+		record, ok := store.Get("forbidden")
+		if !ok || record.Completed || record.Result != "" || len(client.updates) != 2 {
+			t.Fatalf("expected an incomplete record without updates, got %+v %v", record, client.updates)
+		}
+	})
+
+	t.Run("rejects the retries of an operation in progress without blocking the others", func(t *testing.T) {
+		codes := make(chan int)
+		go func() { codes <- receive("slow", fulfillment.ActionUnsubscribe, "Succeeded") }()
+		<-started
+
+		if code := receive("slow", fulfillment.ActionUnsubscribe, "Succeeded"); code != http.StatusConflict {
+			t.Fatalf("expected %d, got %d", http.StatusConflict, code)
+		}
+		if code := receive("suspend", fulfillment.ActionSuspend, "Succeeded"); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		close(released)
+		if code := <-codes; code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("loads the operations of the file", func(t *testing.T) {
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		reloaded, err := fulfillment.NewOperationStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reloaded.Close()
+
+		record, ok := reloaded.Get("change-quantity")
+		if !ok || !record.Completed || record.Result != fulfillment.OperationFailure {
+			t.Fatalf("expected the completed record, got %+v", record)
+		}
+	})
+}
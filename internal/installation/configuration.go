@@ -0,0 +1,34 @@
+// Package installation provides the registry of the managed application installations, kept from their notifications
+package installation
+
+import (
+	"errors"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Configuration represents the configuration of the notifications of the managed application.
+type Configuration struct {
+	// Enabled serves the notification endpoint, which is configured as the notification endpoint of the offer
+	Enabled bool `envconfig:"MANAGED_APP_NOTIFICATIONS_ENABLED" default:"false"`
+	// Secret is the sig query parameter of the notification endpoint URL, which is required when it is enabled.
+	// Azure doesn't send custom headers to the endpoint, the access logs redact the parameter instead.
+	Secret string `envconfig:"MANAGED_APP_NOTIFICATIONS_SECRET" default:""`
+	// Path is the file the installations are kept in, in memory when empty
+	Path string `envconfig:"MANAGED_APP_INSTALLATIONS_PATH" default:""`
+}
+
+// LoadFromEnvVars reads all env vars required for the notifications.
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+func (c Configuration) validate() error {
+	if c.Enabled && c.Secret == "" {
+		return errors.New("MANAGED_APP_NOTIFICATIONS_SECRET is required when the notifications are enabled")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Package installation provides the registry of the managed application installations, kept from their notifications
+package installation
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+)
+
+// RESTController defines the rest controller of the notifications of the managed application
+type RESTController struct {
+	logger        logging.Logger
+	configuration Configuration
+	registry      Registry
+	guard         auth.Guard
+}
+
+// NewRESTController initializes the rest controller, the guard protects the list of the installations
+// since Azure sends the notifications without credentials
+func NewRESTController(
+	logger logging.Logger,
+	registry Registry,
+	guard auth.Guard,
+	configuration Configuration,
+) RESTController {
+	return RESTController{
+		logger:        logger,
+		configuration: configuration,
+		registry:      registry,
+		guard:         guard,
+	}
+}
+
+// Boot ...
+// It refuses to serve the notifications without a secret, since Azure sends them without credentials.
+func (r RESTController) Boot(s server.Server) {
+	if err := r.configuration.validate(); err != nil {
+		r.logger.Fatal(err)
+	}
+
+	s.Router().POST("/resource", r.verify(), r.guard.Validate(), r.notify())
+	s.Router().GET("/installations", r.guard.Require(auth.ScopeMeteringRead), r.list())
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r RESTController) Describe(spec *openapi.Spec) {
+	spec.AddOperation(http.MethodPost, "/resource", openapi.Operation{
+		ID:      "notifyInstallation",
+		Summary: "Receives a lifecycle notification of a managed application installation",
+		Parameters: openapi3.Parameters{{Value: openapi3.NewQueryParameter("sig").
+			WithDescription("Secret of the notification endpoint").
+			WithRequired(true).
+			WithSchema(openapi3.NewStringSchema())}},
+		Request:   spec.Schema(Notification{}, "eventType", "applicationId", "provisioningState"),
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: nil},
+	})
+
+	spec.AddOperation(http.MethodGet, "/installations", openapi.Operation{
+		ID:      "listInstallations",
+		Summary: "Lists the managed application installations",
+		Responses: map[int]*openapi3.SchemaRef{
+			http.StatusOK: openapi3.NewArraySchema().WithItems(spec.Schema(Installation{}).Value).NewRef(),
+		},
+	})
+}
+
+// verify rejects the notifications without the secret before they are validated.
+// The digests are compared, so the time of the comparison doesn't depend on the length of the secret either.
+func (r RESTController) verify() gin.HandlerFunc {
+	secret := sha256.Sum256([]byte(r.configuration.Secret))
+	return func(ctx *gin.Context) {
+		signature := sha256.Sum256([]byte(ctx.Query("sig")))
+		if r.configuration.Secret == "" || subtle.ConstantTimeCompare(signature[:], secret[:]) != 1 {
+			correlation.Logger(ctx, r.logger).Warnf("notification from %s has an invalid signature", ctx.ClientIP())
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid notification signature"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func (r RESTController) notify() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		notification := Notification{}
+		if err := ctx.ShouldBindJSON(&notification); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if notification.EventTime.IsZero() {
+			notification.EventTime = time.Now().UTC()
+		}
+
+		installation, err := r.registry.Apply(notification)
+		if err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("failed to record notification of %s with error %v", notification.ApplicationID, err)
+			// Azure retries the notifications the endpoint doesn't accept
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		correlation.Logger(ctx, r.logger).Infof("installation %s is %s after %s notification (billable: %v)",
+			installation.ApplicationID, installation.ProvisioningState, notification.EventType, installation.Billable())
+
+		ctx.Status(http.StatusOK)
+	}
+}
+
+func (r RESTController) list() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, r.registry.List())
+	}
+}
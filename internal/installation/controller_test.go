@@ -0,0 +1,176 @@
+package installation_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/installation"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+const applicationID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Solutions/applications/app"
+
+func TestNotifications(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	path := filepath.Join(t.TempDir(), "installations.ndjson")
+	plans, err := metering.NewPlanHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := installation.NewRegistry(path, plans)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	installation.NewRESTController(logger, registry, auth.NewGuard(logger, nil),
+		installation.Configuration{Enabled: true, Secret: "secret"}).Boot(httpServer)
+
+	notify := func(signature string, body string) int {
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodPost, "/resource?sig="+signature, bytes.NewBufferString(body)))
+		return recorder.Code
+	}
+
+	notification := func(eventType, state, eventTime string) string {
+		return fmt.Sprintf(`{"eventType":"%s","applicationId":"%s","eventTime":"%s","provisioningState":"%s",`+
+			`"billingDetails":{"resourceUsageId":"usage-id"},"plan":{"publisher":"ydata","product":"fabric","name":"gold"}}`,
+			eventType, applicationID, eventTime, state)
+	}
+
+	t.Run("rejects notifications without the secret", func(t *testing.T) {
+		if code := notify("other", notification("PUT", "Succeeded", "2024-05-01T10:00:00Z")); code != http.StatusUnauthorized {
+			t.Fatalf("expected %d, got %d", http.StatusUnauthorized, code)
+		}
+		if code := notify("", `{"eventType":1}`); code != http.StatusUnauthorized {
+			t.Fatalf("expected %d before validating the notification, got %d", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("bills the provisioned installations", func(t *testing.T) {
+		if code := notify("secret", notification("PUT", "Succeeded", "2024-05-01T10:00:00Z")); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		if reason, skip := registry.SkipReason(applicationID); skip {
+			t.Fatalf("expected the installation to be billable, got %s", reason)
+		}
+		for _, resource := range []string{applicationID, "USAGE-ID"} {
+			if planID, _ := plans.PlanID(resource, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)); planID != "gold" {
+				t.Fatalf("expected the plan of the installation for %s, got '%s'", resource, planID)
+			}
+		}
+	})
+
+	t.Run("keeps billing the installations whose update failed", func(t *testing.T) {
+		if code := notify("secret", notification("PATCH", "Failed", "2024-05-10T10:00:00Z")); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		if reason, skip := registry.SkipReason(applicationID); skip {
+			t.Fatalf("expected the installation to be billable, got %s", reason)
+		}
+	})
+
+	t.Run("stops billing the deleted installations", func(t *testing.T) {
+		if code := notify("secret", notification("DELETE", "Deleted", "2024-06-01T10:00:00Z")); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+		// Azure doesn't guarantee the order of the notifications
+		if code := notify("secret", notification("PATCH", "Succeeded", "2024-05-15T10:00:00Z")); code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		for _, resource := range []string{applicationID, "USAGE-ID"} {
+			if _, skip := registry.SkipReason(resource); !skip {
+				t.Fatalf("expected the usage of %s to be skipped", resource)
+			}
+		}
+
+		ctrl := gomock.NewController(t)
+		client := metering.NewInstallationClient(mock.NewMockMeteringClient(ctrl), applicationID, registry, logger)
+		response, err := client.CreateUsageEvent(context.Background(), coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1})
+		if err != nil || response.Status != metering.StatusSkipped {
+			t.Fatalf("expected the event to be skipped, got %+v %v", response, err)
+		}
+	})
+
+	t.Run("loads the installations of the file", func(t *testing.T) {
+		if err := registry.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		reloaded, err := installation.NewRegistry(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reloaded.Close()
+
+		installations := reloaded.List()
+		if len(installations) != 1 || installations[0].ProvisioningState != installation.StateDeleted ||
+			installations[0].Plan.Name != "gold" {
+			t.Fatalf("expected the deleted installation, got %+v", installations)
+		}
+	})
+}
+
+func TestConfigurationRequiresSecret(t *testing.T) {
+	t.Setenv("MANAGED_APP_NOTIFICATIONS_ENABLED", "true")
+	t.Setenv("MANAGED_APP_NOTIFICATIONS_SECRET", "")
+
+	configuration := installation.Configuration{}
+	if err := configuration.LoadFromEnvVars(); err == nil {
+		t.Fatal("should require the secret when the notifications are enabled")
+	}
+
+	t.Setenv("MANAGED_APP_NOTIFICATIONS_SECRET", "secret")
+	if err := configuration.LoadFromEnvVars(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+}
+
+func TestBillable(t *testing.T) {
+	tt := []struct {
+		name         string
+		installation installation.Installation
+		billable     bool
+	}{
+		{name: "provisioned", installation: installation.Installation{ProvisioningState: "Succeeded", Provisioned: true}, billable: true},
+		{name: "failed provisioning", installation: installation.Installation{ProvisioningState: "Failed"}, billable: false},
+		{name: "failed update", installation: installation.Installation{ProvisioningState: "Failed", Provisioned: true}, billable: true},
+		{name: "deleted", installation: installation.Installation{ProvisioningState: "Deleted", Provisioned: true}, billable: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if billable := tc.installation.Billable(); billable != tc.billable {
+				t.Fatalf("expected billable %v, got %v", tc.billable, billable)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package installation provides the registry of the managed application installations, kept from their notifications
+package installation
+
+import (
+	"strings"
+	"time"
+)
+
+// Event types of the notifications
+const (
+	EventPut    = "PUT"
+	EventPatch  = "PATCH"
+	EventDelete = "DELETE"
+)
+
+// Provisioning states of the installations
+const (
+	StateAccepted  = "Accepted"
+	StateSucceeded = "Succeeded"
+	StateFailed    = "Failed"
+	StateDeleting  = "Deleting"
+	StateDeleted   = "Deleted"
+)
+
+// Notification represents a lifecycle notification Azure sends for a managed application
+type Notification struct {
+	EventType         string             `json:"eventType" binding:"required"`
+	ApplicationID     string             `json:"applicationId" binding:"required"`
+	EventTime         time.Time          `json:"eventTime"`
+	ProvisioningState string             `json:"provisioningState" binding:"required"`
+	BillingDetails    *BillingDetails    `json:"billingDetails,omitempty"`
+	Plan              *Plan              `json:"plan,omitempty"`
+	Error             *NotificationError `json:"error,omitempty"`
+}
+
+// BillingDetails represents the billing identity of the installation
+type BillingDetails struct {
+	ResourceUsageID string `json:"resourceUsageId"`
+}
+
+// Plan represents the marketplace plan of the installation
+type Plan struct {
+	Publisher string `json:"publisher"`
+	Product   string `json:"product"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+}
+
+// NotificationError represents the error of a failed provisioning
+type NotificationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Installation represents the latest known state of a managed application
+type Installation struct {
+	ApplicationID     string    `json:"applicationId"`
+	ResourceUsageID   string    `json:"resourceUsageId,omitempty"`
+	Plan              Plan      `json:"plan"`
+	EventType         string    `json:"eventType"`
+	ProvisioningState string    `json:"provisioningState"`
+	Error             string    `json:"error,omitempty"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+	// Provisioned is true once the installation succeeded, its failures are then failed updates
+	Provisioned bool `json:"provisioned,omitempty"`
+}
+
+// Billable returns false when the installation was deleted or failed to provision, its usage must not be billed.
+// A failed update of a provisioned installation doesn't stop it, so it is still billed.
+func (i Installation) Billable() bool {
+	switch i.ProvisioningState {
+	case StateDeleted:
+		return false
+	case StateFailed:
+		return i.Provisioned
+	default:
+		return true
+	}
+}
+
+// apply returns the installation updated with the notification, the plan and the billing details are kept
+// when the notification doesn't have them
+func (i Installation) apply(notification Notification) Installation {
+	i.ApplicationID = notification.ApplicationID
+	i.EventType = notification.EventType
+	i.ProvisioningState = notification.ProvisioningState
+	i.Provisioned = i.Provisioned || notification.ProvisioningState == StateSucceeded
+	i.UpdatedAt = notification.EventTime
+	i.Error = ""
+
+	if notification.BillingDetails != nil && notification.BillingDetails.ResourceUsageID != "" {
+		i.ResourceUsageID = notification.BillingDetails.ResourceUsageID
+	}
+	if notification.Plan != nil {
+		i.Plan = *notification.Plan
+	}
+	if notification.Error != nil {
+		i.Error = notification.Error.Code + ": " + notification.Error.Message
+	}
+
+	return i
+}
+
+// resourceKey normalizes the resource ids, which Azure compares without case
+func resourceKey(id string) string {
+	return strings.ToLower(id)
+}
@@ -0,0 +1,176 @@
+// Package installation provides the registry of the managed application installations, kept from their notifications
+package installation
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// Registry defines the installations of the managed application, which metering uses to stop billing
+// the deleted and failed ones. The installations it doesn't know of are billable.
+type Registry interface {
+	metering.Installations
+	// Apply records the notification, and returns the installation updated with it.
+	// The notifications older than the installation are ignored, since Azure doesn't guarantee their order.
+	Apply(notification Notification) (Installation, error)
+	// List returns the installations sorted by application id
+	List() []Installation
+	Close() error
+}
+
+type registry struct {
+	plans metering.PlanRecorder
+
+	mu            sync.RWMutex
+	file          *os.File
+	installations map[string]Installation
+	// usageIDs maps the resource usage ids to the application ids, metering identifies an installation by either
+	usageIDs map[string]string
+}
+
+// NewRegistry initializes the registry of the installations, which records the plans of the provisioned ones
+// when the plans aren't nil.
+// When the path isn't empty every change is appended to the file, and the installations already there are loaded.
+func NewRegistry(path string, plans metering.PlanRecorder) (Registry, error) {
+	r := &registry{plans: plans, installations: map[string]Installation{}, usageIDs: map[string]string{}}
+
+	if path == "" {
+		return r, nil
+	}
+
+	if err := r.load(path); err != nil {
+		return nil, fmt.Errorf("could not load installations %s. Err: %v", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r.file = file
+
+	return r, nil
+}
+
+// Apply records the notification, and returns the installation updated with it
+func (r *registry) Apply(notification Notification) (Installation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.installations[resourceKey(notification.ApplicationID)]
+	if ok && notification.EventTime.Before(current.UpdatedAt) {
+		return current, nil
+	}
+
+	installation := current.apply(notification)
+
+	if r.file != nil {
+		if err := json.NewEncoder(r.file).Encode(installation); err != nil {
+			return current, err
+		}
+		if err := r.file.Sync(); err != nil {
+			return current, err
+		}
+	}
+
+	r.set(installation)
+
+	if r.plans != nil && installation.ProvisioningState == StateSucceeded && installation.Plan.Name != "" {
+		// metering looks the plan up by its resource, which is either the application id or the resource usage id
+		for _, resourceURI := range []string{installation.ApplicationID, installation.ResourceUsageID} {
+			if resourceURI == "" {
+				continue
+			}
+			if err := r.plans.RecordPlan(resourceURI, installation.Plan.Name, installation.UpdatedAt); err != nil {
+				return installation, err
+			}
+		}
+	}
+
+	return installation, nil
+}
+
+// List returns the installations sorted by application id
+func (r *registry) List() []Installation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	installations := make([]Installation, 0, len(r.installations))
+	for _, installation := range r.installations {
+		installations = append(installations, installation)
+	}
+	sort.Slice(installations, func(i, j int) bool {
+		return installations[i].ApplicationID < installations[j].ApplicationID
+	})
+
+	return installations
+}
+
+// SkipReason returns the reason why the usage of the application, or of the resource usage id, should not be sent
+func (r *registry) SkipReason(resourceURI string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := resourceKey(resourceURI)
+	if applicationID, ok := r.usageIDs[key]; ok {
+		key = applicationID
+	}
+
+	installation, ok := r.installations[key]
+	if !ok || installation.Billable() {
+		return "", false
+	}
+
+	return fmt.Sprintf("installation '%s' is %s", installation.ApplicationID, installation.ProvisioningState), true
+}
+
+func (r *registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+func (r *registry) set(installation Installation) {
+	key := resourceKey(installation.ApplicationID)
+	r.installations[key] = installation
+	if installation.ResourceUsageID != "" {
+		r.usageIDs[resourceKey(installation.ResourceUsageID)] = key
+	}
+}
+
+// load reads the installations of the file, the last line of an installation is its latest state
+func (r *registry) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		installation := Installation{}
+		if err := json.Unmarshal(scanner.Bytes(), &installation); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		r.set(installation)
+	}
+
+	return scanner.Err()
+}
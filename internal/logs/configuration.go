@@ -0,0 +1,18 @@
+// Package logs provides the loggers that redact the sensitive values and prefix the lines of a request
+package logs
+
+import (
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Configuration represents the configuration of the redaction of the logs.
+type Configuration struct {
+	// RedactFields are redacted from the logged values besides the authorization headers and the tokens,
+	// e.g. the names of the fields of a payload or of the parameters of a query
+	RedactFields []string `envconfig:"LOG_REDACT_FIELDS" default:""`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
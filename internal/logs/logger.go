@@ -0,0 +1,82 @@
+// Package logs provides the loggers that redact the sensitive values and prefix the lines of a request
+package logs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// messageLogger formats the messages and rewrites them before the logger writes them
+type messageLogger struct {
+	logger  logging.Logger
+	rewrite func(message string) string
+}
+
+// WithPrefix returns a logger that starts every message with the prefix
+func WithPrefix(logger logging.Logger, prefix string) logging.Logger {
+	return messageLogger{logger: logger, rewrite: func(message string) string {
+		return prefix + message
+	}}
+}
+
+func (l messageLogger) sprint(args []interface{}) string {
+	return l.rewrite(fmt.Sprint(args...))
+}
+
+func (l messageLogger) sprintf(format string, args []interface{}) string {
+	return l.rewrite(fmt.Sprintf(format, args...))
+}
+
+func (l messageLogger) sprintln(args []interface{}) string {
+	return l.rewrite(strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (l messageLogger) Trace(args ...interface{})   { l.logger.Trace(l.sprint(args)) }
+func (l messageLogger) Debug(args ...interface{})   { l.logger.Debug(l.sprint(args)) }
+func (l messageLogger) Print(args ...interface{})   { l.logger.Print(l.sprint(args)) }
+func (l messageLogger) Info(args ...interface{})    { l.logger.Info(l.sprint(args)) }
+func (l messageLogger) Warn(args ...interface{})    { l.logger.Warn(l.sprint(args)) }
+func (l messageLogger) Warning(args ...interface{}) { l.logger.Warning(l.sprint(args)) }
+func (l messageLogger) Error(args ...interface{})   { l.logger.Error(l.sprint(args)) }
+func (l messageLogger) Panic(args ...interface{})   { l.logger.Panic(l.sprint(args)) }
+func (l messageLogger) Fatal(args ...interface{})   { l.logger.Fatal(l.sprint(args)) }
+
+func (l messageLogger) Tracef(format string, args ...interface{}) {
+	l.logger.Trace(l.sprintf(format, args))
+}
+func (l messageLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(l.sprintf(format, args))
+}
+func (l messageLogger) Printf(format string, args ...interface{}) {
+	l.logger.Print(l.sprintf(format, args))
+}
+func (l messageLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(l.sprintf(format, args))
+}
+func (l messageLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(l.sprintf(format, args))
+}
+func (l messageLogger) Warningf(format string, args ...interface{}) {
+	l.logger.Warning(l.sprintf(format, args))
+}
+func (l messageLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(l.sprintf(format, args))
+}
+func (l messageLogger) Panicf(format string, args ...interface{}) {
+	l.logger.Panic(l.sprintf(format, args))
+}
+func (l messageLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatal(l.sprintf(format, args))
+}
+
+func (l messageLogger) Traceln(args ...interface{})   { l.logger.Trace(l.sprintln(args)) }
+func (l messageLogger) Debugln(args ...interface{})   { l.logger.Debug(l.sprintln(args)) }
+func (l messageLogger) Println(args ...interface{})   { l.logger.Print(l.sprintln(args)) }
+func (l messageLogger) Infoln(args ...interface{})    { l.logger.Info(l.sprintln(args)) }
+func (l messageLogger) Warnln(args ...interface{})    { l.logger.Warn(l.sprintln(args)) }
+func (l messageLogger) Warningln(args ...interface{}) { l.logger.Warning(l.sprintln(args)) }
+func (l messageLogger) Errorln(args ...interface{})   { l.logger.Error(l.sprintln(args)) }
+func (l messageLogger) Panicln(args ...interface{})   { l.logger.Panic(l.sprintln(args)) }
+func (l messageLogger) Fatalln(args ...interface{})   { l.logger.Fatal(l.sprintln(args)) }
@@ -0,0 +1,114 @@
+// Package logs provides the loggers that redact the sensitive values and prefix the lines of a request
+package logs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// Redacted replaces the sensitive values in the logs
+const Redacted = "[REDACTED]"
+
+// headerFields are the headers whose values have spaces, e.g. the scheme of the authorization
+var headerFields = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// defaultFields are the fields of the tokens and secrets, which are redacted besides the configured ones
+var defaultFields = []string{
+	"access_token", "refresh_token", "id_token", "client_secret", "clientsecret", "password", "secret", "token", "sig",
+	"x-ms-marketplace-token",
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// Redactor strips the authorization headers, the tokens and the configured fields from logged values
+type Redactor struct {
+	headers *regexp.Regexp
+	fields  *regexp.Regexp
+}
+
+// NewRedactor initializes a redactor of the default fields and of the fields, compared without case
+func NewRedactor(fields []string) Redactor {
+	names := []string{}
+	for _, field := range append(append([]string{}, defaultFields...), fields...) {
+		if field = strings.TrimSpace(field); field != "" {
+			names = append(names, regexp.QuoteMeta(field))
+		}
+	}
+
+	quoted := []string{}
+	for _, field := range headerFields {
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+
+	return Redactor{
+		// e.g. Authorization:[Bearer ...], "Authorization": "Bearer ..." or Authorization: Bearer ...
+		headers: regexp.MustCompile(`(?i)(\b(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*\[?"?)[^\]"\n,]+`),
+		// e.g. "token":"...", token=..., Token:... or map[token:...], the name may end a longer one like ClientSecret
+		fields: regexp.MustCompile(`(?i)([A-Za-z0-9_-]*(?:` + strings.Join(names, "|") + `)"?\s*[:=]\s*"?)[^"\s,&}\])]+`),
+	}
+}
+
+// Redact returns the message without the sensitive values
+func (r Redactor) Redact(message string) string {
+	message = r.headers.ReplaceAllString(message, "${1}"+Redacted)
+	message = bearerPattern.ReplaceAllString(message, "Bearer "+Redacted)
+	message = jwtPattern.ReplaceAllString(message, Redacted)
+	return r.fields.ReplaceAllString(message, "${1}"+Redacted)
+}
+
+// Header returns the header in a deterministic order, without the values of the sensitive headers
+func (r Redactor) Header(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, fmt.Sprintf("%s:%v", key, header[key]))
+	}
+	return r.Redact("map[" + strings.Join(values, " ") + "]")
+}
+
+// Response returns the status and the headers of the response, instead of the whole response and its request
+func (r Redactor) Response(response *http.Response) string {
+	if response == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%s %s", response.Status, r.Header(response.Header))
+}
+
+// NewRedactingLogger returns a logger that redacts the sensitive values of every message
+func NewRedactingLogger(logger logging.Logger, configuration Configuration) logging.Logger {
+	redactor := NewRedactor(configuration.RedactFields)
+	return messageLogger{logger: logger, rewrite: redactor.Redact}
+}
+
+// NewRedactingWriter returns a writer that redacts the sensitive values of every line, e.g. of the access logs,
+// which print the query of the requests
+func NewRedactingWriter(w io.Writer, configuration Configuration) io.Writer {
+	return redactingWriter{writer: w, redactor: NewRedactor(configuration.RedactFields)}
+}
+
+// redactingWriter expects the lines to be written at once, as the loggers do
+type redactingWriter struct {
+	writer   io.Writer
+	redactor Redactor
+}
+
+func (w redactingWriter) Write(data []byte) (int, error) {
+	if _, err := io.WriteString(w.writer, w.redactor.Redact(string(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
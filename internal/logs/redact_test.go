@@ -0,0 +1,94 @@
+package logs_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ydataai/azure-adapter/internal/logs"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := logs.NewRedactor([]string{"planId"})
+
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "header map",
+			message:  "map[Authorization:[Bearer abc.def] Content-Type:[application/json]]",
+			expected: "map[Authorization:[[REDACTED]] Content-Type:[application/json]]",
+		},
+		{
+			name:     "bearer token",
+			message:  "calling with bearer abc123",
+			expected: "calling with Bearer [REDACTED]",
+		},
+		{
+			name:     "jwt",
+			message:  "resolving eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl",
+			expected: "resolving [REDACTED]",
+		},
+		{
+			name:     "json fields",
+			message:  `{"access_token":"abc","clientSecret":"def","name":"contoso"}`,
+			expected: `{"access_token":"[REDACTED]","clientSecret":"[REDACTED]","name":"contoso"}`,
+		},
+		{
+			name:     "configured field",
+			message:  "updating subscription with {PlanID:gold Quantity:1}",
+			expected: "updating subscription with {PlanID:[REDACTED] Quantity:1}",
+		},
+		{
+			name:     "query",
+			message:  "https://storage/blob?sv=2020&sig=abc&se=2021",
+			expected: "https://storage/blob?sv=2020&sig=[REDACTED]&se=2021",
+		},
+		{
+			name:     "no sensitive values",
+			message:  "got event {DimensionID:gpu Quantity:1}",
+			expected: "got event {DimensionID:gpu Quantity:1}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, redactor.Redact(tt.message)); diff != "" {
+				t.Fatalf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRedactorResponse(t *testing.T) {
+	redactor := logs.NewRedactor(nil)
+	response := &http.Response{
+		Status: "200 OK",
+		Header: http.Header{"Set-Cookie": {"session=abc"}, "X-Ms-Requestid": {"id"}},
+	}
+
+	expected := "200 OK map[Set-Cookie:[[REDACTED]] X-Ms-Requestid:[id]]"
+	if diff := cmp.Diff(expected, redactor.Response(response)); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRedactingWriter(t *testing.T) {
+	output := &bytes.Buffer{}
+	writer := logs.NewRedactingWriter(output, logs.Configuration{})
+
+	line := `[GIN] 2024/05/01 - 10:00:00 | 200 |  1ms | 10.0.0.1 | POST "/resource?sig=secret"` + "\n"
+	n, err := writer.Write([]byte(line))
+	if err != nil || n != len(line) {
+		t.Fatalf("should write the whole line, got %d %v", n, err)
+	}
+
+	expected := `[GIN] 2024/05/01 - 10:00:00 | 200 |  1ms | 10.0.0.1 | POST "/resource?sig=[REDACTED]"` + "\n"
+	if diff := cmp.Diff(expected, output.String()); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,82 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// AlertKind defines the condition an alert reports
+type AlertKind string
+
+// Supported alert kinds
+const (
+	// AlertCapThreshold reports that the usage of a dimension crossed a threshold of its monthly cap
+	AlertCapThreshold AlertKind = "capThreshold"
+	// AlertCapExceeded reports that usage events of a dimension were truncated, skipped or held by its monthly cap
+	AlertCapExceeded AlertKind = "capExceeded"
+	// AlertSubmissionFailed reports that usage events couldn't be sent to azure
+	AlertSubmissionFailed AlertKind = "submissionFailed"
+	// AlertEventExpired reports that an usage event started too long ago for azure to accept it
+	AlertEventExpired AlertKind = "eventExpired"
+	// AlertRepeatedRejections reports that the usage events of a dimension are rejected one after the other
+	AlertRepeatedRejections AlertKind = "repeatedRejections"
+	// AlertCircuitOpen reports that the usage events stopped being sent after consecutive failures
+	AlertCircuitOpen AlertKind = "circuitOpen"
+)
+
+// Alert represents a condition of the metering that operators should know of
+type Alert struct {
+	Kind        AlertKind `json:"kind"`
+	TenantID    string    `json:"tenantId,omitempty"`
+	ResourceURI string    `json:"resourceUri,omitempty"`
+	DimensionID string    `json:"dimensionId,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Message     string    `json:"message"`
+	Time        time.Time `json:"time"`
+	// Count is the number of consecutive failures or rejections, for the alerts that count them
+	Count int `json:"count,omitempty"`
+	// Cap is the state of the monthly cap of the dimension, for the cap alerts
+	Cap *CapUsage `json:"cap,omitempty"`
+}
+
+// CapUsage represents the usage of a dimension in a billing month against its cap
+type CapUsage struct {
+	Month     time.Time `json:"month"`
+	Cap       float64   `json:"cap"`
+	Quantity  float64   `json:"quantity"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Policy    CapPolicy `json:"policy"`
+}
+
+// Alerter defines the delivery of the alerts, which must not block the usage events
+type Alerter interface {
+	Alert(ctx context.Context, alert Alert)
+}
+
+// dedupKey identifies the alerts of the same condition, which are only delivered once within a window
+func (a Alert) dedupKey() string {
+	key := fmt.Sprintf("%s|%s|%s|%s|%s", a.Kind, a.TenantID, a.ResourceURI, a.DimensionID, a.Status)
+	if a.Cap != nil {
+		key += fmt.Sprintf("|%s|%v", a.Cap.Month.Format("2006-01"), a.Cap.Threshold)
+	}
+	return key
+}
+
+type logAlerter struct {
+	logger logging.Logger
+}
+
+// NewLogAlerter initializes an alerter that writes the alerts to the log
+func NewLogAlerter(logger logging.Logger) Alerter {
+	return logAlerter{logger: logger}
+}
+
+func (a logAlerter) Alert(ctx context.Context, alert Alert) {
+	correlation.Logger(ctx, a.logger).Warnf("alert %s of tenant '%s': %s", alert.Kind, alert.TenantID, alert.Message)
+}
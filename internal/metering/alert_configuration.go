@@ -0,0 +1,27 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// AlertConfiguration represents the configuration of the outbound webhooks of the metering alerts.
+// The alerts of the same condition are delivered once within the dedup window, and when the signing secret
+// is set every payload is signed with HMAC-SHA256.
+type AlertConfiguration struct {
+	WebhookURL      string            `envconfig:"METERING_ALERT_WEBHOOK_URL" default:""`
+	SlackWebhookURL string            `envconfig:"METERING_ALERT_SLACK_WEBHOOK_URL" default:""`
+	TeamsWebhookURL string            `envconfig:"METERING_ALERT_TEAMS_WEBHOOK_URL" default:""`
+	WebhookHeaders  map[string]string `envconfig:"METERING_ALERT_WEBHOOK_HEADERS" default:""`
+	WebhookTimeout  time.Duration     `envconfig:"METERING_ALERT_WEBHOOK_TIMEOUT" default:"10s"`
+	SigningSecret   string            `envconfig:"METERING_ALERT_SIGNING_SECRET" default:""`
+	DedupWindow     time.Duration     `envconfig:"METERING_ALERT_DEDUP_WINDOW" default:"15m"`
+	QueueSize       int               `envconfig:"METERING_ALERT_QUEUE_SIZE" default:"100"`
+}
+
+// LoadFromEnvVars reads all env vars required for the metering alerts.
+func (c *AlertConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
@@ -0,0 +1,151 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// ErrCircuitOpen is returned without sending the usage events while the circuit breaker is open
+var ErrCircuitOpen = errors.New("metering circuit breaker is open")
+
+type breakerClient struct {
+	client      Client
+	resourceURI string
+	alerter     Alerter
+	logger      logging.Logger
+
+	mu          sync.Mutex
+	failures    int
+	cooldown    time.Duration
+	consecutive int
+	openUntil   time.Time
+	// probing is true while the request that decides whether the circuit closes again is sent
+	probing bool
+}
+
+// NewBreakerClient initializes a client that stops sending the usage events for the cooldown after the
+// consecutive failures of the configuration, failing them with ErrCircuitOpen. Once the cooldown ends
+// a single request is sent, which closes the circuit when it succeeds or opens it again when it fails.
+// The circuit never opens while the consecutive failures are zero.
+func NewBreakerClient(client Client, config Configuration, logger logging.Logger) Client {
+	return &breakerClient{
+		client:      client,
+		resourceURI: config.ResourceUri,
+		failures:    config.CircuitBreakerFailures,
+		cooldown:    config.CircuitBreakerCooldown,
+		alerter:     config.alerter(logger),
+		logger:      logger,
+	}
+}
+
+// CreateUsageEvent sends the event with the client while the circuit is closed
+func (c *breakerClient) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (UsageEventResponse, error) {
+	if err := c.allow(); err != nil {
+		return UsageEventResponse{}, err
+	}
+
+	response, err := c.client.CreateUsageEvent(ctx, event)
+	c.record(ctx, err)
+
+	return response, err
+}
+
+// BatchCreateUsageEvent sends the batch with the client while the circuit is closed
+func (c *breakerClient) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*UsageEventBatchResponse, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.BatchCreateUsageEvent(ctx, batch)
+	c.record(ctx, err)
+
+	return response, err
+}
+
+// Reload applies the consecutive failures and the cooldown, and forwards the configuration to the client.
+// The circuit closes when the breaker is disabled, an open circuit keeps the cooldown it was opened with.
+func (c *breakerClient) Reload(config Configuration) {
+	c.mu.Lock()
+	c.failures = config.CircuitBreakerFailures
+	c.cooldown = config.CircuitBreakerCooldown
+	if c.failures <= 0 {
+		c.consecutive, c.openUntil, c.probing = 0, time.Time{}, false
+	}
+	c.mu.Unlock()
+
+	if reloadable, ok := c.client.(Reloadable); ok {
+		reloadable.Reload(config)
+	}
+}
+
+// allow returns ErrCircuitOpen during the cooldown, and while the request after it is being sent
+func (c *breakerClient) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.openUntil.IsZero() {
+		return nil
+	}
+
+	if c.probing || time.Now().Before(c.openUntil) {
+		return fmt.Errorf("%w until %s", ErrCircuitOpen, c.openUntil.UTC().Format(time.RFC3339))
+	}
+
+	c.probing = true
+	return nil
+}
+
+// record counts the consecutive failures and opens the circuit once they reach the limit.
+// The requests canceled by their callers aren't failures of azure.
+func (c *breakerClient) record(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		c.mu.Lock()
+		c.probing = false
+		c.mu.Unlock()
+		return
+	}
+
+	c.mu.Lock()
+	if err == nil || c.failures <= 0 {
+		c.consecutive, c.openUntil, c.probing = 0, time.Time{}, false
+		c.mu.Unlock()
+		return
+	}
+
+	c.consecutive++
+	if !c.probing && c.consecutive < c.failures {
+		c.mu.Unlock()
+		return
+	}
+
+	cooldown := c.cooldown
+	c.openUntil = time.Now().Add(cooldown)
+	c.probing = false
+	consecutive := c.consecutive
+	c.mu.Unlock()
+
+	correlation.Logger(ctx, c.logger).Errorf("metering circuit breaker opened for %v after %d consecutive failures. Err: %v",
+		cooldown, consecutive, err)
+	c.alerter.Alert(ctx, Alert{
+		Kind:        AlertCircuitOpen,
+		TenantID:    TenantFromContext(ctx),
+		ResourceURI: c.resourceURI,
+		Message: fmt.Sprintf("usage events stopped being sent for %v after %d consecutive failures, last error: %v",
+			cooldown, consecutive, err),
+		Time:  time.Now().UTC(),
+		Count: consecutive,
+	})
+}
@@ -0,0 +1,182 @@
+package metering_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestBreakerClient(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: time.Now()}
+	unavailable := errors.New("service unavailable")
+
+	primary := mock.NewMockMeteringClient(ctrl)
+	gomock.InOrder(
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), event).Return(metering.UsageEventResponse{}, unavailable).Times(2),
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), event).Return(accepted(event), nil),
+	)
+
+	alerter := &fakeAlerter{}
+	configuration := metering.Configuration{
+		CircuitBreakerFailures:  2,
+		CircuitBreakerCooldown:  50 * time.Millisecond,
+		RejectionAlertThreshold: 3,
+		Alerter:                 alerter,
+	}
+	client := metering.NewMonitorClient(metering.NewBreakerClient(primary, configuration, logger), configuration, logger)
+	ctx := context.Background()
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := client.CreateUsageEvent(ctx, event); !errors.Is(err, unavailable) {
+			t.Fatalf("attempt %d: expected the error of azure, got %v", attempt, err)
+		}
+	}
+
+	if _, err := client.CreateUsageEvent(ctx, event); !errors.Is(err, metering.ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if response, err := client.CreateUsageEvent(ctx, event); err != nil || response.Status != metering.StatusAccepted {
+		t.Fatalf("expected the circuit to close, got %+v %v", response, err)
+	}
+
+	alerts := []metering.AlertKind{}
+	for _, alert := range alerter.alerts {
+		alerts = append(alerts, alert.Kind)
+	}
+	// the breaker alerts before the monitor reports the failure that opened it, and not of the refused request
+	expected := []metering.AlertKind{
+		metering.AlertSubmissionFailed, metering.AlertCircuitOpen, metering.AlertSubmissionFailed,
+	}
+	if diff := cmp.Diff(expected, alerts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMonitorClient(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: time.Now().AddDate(0, 0, -2)}
+	expired := metering.UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{DimensionID: "gpu", Status: metering.StatusExpired},
+	}
+	batch := coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{event, event}}
+
+	// the events the adapter refused by its duplicate policy weren't sent, they aren't rejections of azure
+	duplicate := coreMetering.UsageEvent{DimensionID: "cpu", Quantity: 1, StartAt: time.Now()}
+	rejected := metering.UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{DimensionID: "cpu", Status: metering.StatusRejected},
+	}
+
+	primary := mock.NewMockMeteringClient(ctrl)
+	primary.EXPECT().BatchCreateUsageEvent(gomock.Any(), batch).
+		Return(&metering.UsageEventBatchResponse{Result: []metering.UsageEventResponse{expired, expired}}, nil).Times(2)
+	primary.EXPECT().CreateUsageEvent(gomock.Any(), duplicate).Return(rejected, nil).Times(3)
+
+	alerter := &fakeAlerter{}
+	client := metering.NewMonitorClient(primary,
+		metering.Configuration{ResourceUri: "resource", RejectionAlertThreshold: 3, Alerter: alerter}, logger)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.BatchCreateUsageEvent(metering.WithTenant(context.Background(), "contoso"), batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.CreateUsageEvent(metering.WithTenant(context.Background(), "contoso"), duplicate); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kinds := map[metering.AlertKind]int{}
+	for _, alert := range alerter.alerts {
+		kinds[alert.Kind]++
+		if alert.TenantID != "contoso" || alert.ResourceURI != "resource" || alert.DimensionID != "gpu" {
+			t.Fatalf("unexpected alert %+v", alert)
+		}
+	}
+	expected := map[metering.AlertKind]int{metering.AlertEventExpired: 4, metering.AlertRepeatedRejections: 1}
+	if diff := cmp.Diff(expected, kinds); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBreakerClientReload(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: time.Now()}
+	unavailable := errors.New("service unavailable")
+
+	primary := mock.NewMockMeteringClient(ctrl)
+	gomock.InOrder(
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), event).Return(metering.UsageEventResponse{}, unavailable).Times(2),
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), event).Return(accepted(event), nil),
+	)
+
+	client := metering.NewBreakerClient(primary, metering.Configuration{}, logger)
+	ctx := context.Background()
+
+	if _, err := client.CreateUsageEvent(ctx, event); !errors.Is(err, unavailable) {
+		t.Fatalf("expected the error of azure, got %v", err)
+	}
+
+	client.(metering.Reloadable).Reload(metering.Configuration{
+		CircuitBreakerFailures: 1,
+		CircuitBreakerCooldown: time.Hour,
+	})
+
+	if _, err := client.CreateUsageEvent(ctx, event); !errors.Is(err, unavailable) {
+		t.Fatalf("expected the error of azure, got %v", err)
+	}
+	if _, err := client.CreateUsageEvent(ctx, event); !errors.Is(err, metering.ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to open after the reloaded failures, got %v", err)
+	}
+
+	client.(metering.Reloadable).Reload(metering.Configuration{})
+
+	if response, err := client.CreateUsageEvent(ctx, event); err != nil || response.Status != metering.StatusAccepted {
+		t.Fatalf("expected the circuit to close once the breaker is disabled, got %+v %v", response, err)
+	}
+}
@@ -0,0 +1,394 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// capHoldWindow is how long the events are held, azure rejects the usage that started more than 24 hours ago
+const capHoldWindow = 24 * time.Hour
+
+type capKey struct {
+	month       time.Time
+	dimensionID string
+}
+
+func newCapKey(event coreMetering.UsageEvent) capKey {
+	startAt := event.StartAt.UTC()
+	return capKey{
+		month:       time.Date(startAt.Year(), startAt.Month(), 1, 0, 0, 0, 0, time.UTC),
+		dimensionID: event.DimensionID,
+	}
+}
+
+// capAdmission is an event admitted below the cap, with the quantity reserved for it until azure answers,
+// or the response of the event when it isn't sent. capped tells whether the quantity was reserved.
+type capAdmission struct {
+	event    coreMetering.UsageEvent
+	key      capKey
+	reserved float64
+	capped   bool
+	reason   string
+	response *UsageEventResponse
+}
+
+// heldEvent is an event above the cap, kept with its tenant until the caps are reloaded
+type heldEvent struct {
+	tenantID string
+	event    coreMetering.UsageEvent
+}
+
+type capClient struct {
+	client      FanOutClient
+	resourceURI string
+	alerter     Alerter
+	logger      logging.Logger
+
+	mu     sync.Mutex
+	config Configuration
+	// accepted is the quantity azure accepted in each month and dimension, and pending the quantity still being sent
+	accepted map[capKey]float64
+	pending  map[capKey]float64
+	exceeded map[capKey]bool
+	held     []heldEvent
+	releases sync.WaitGroup
+}
+
+// NewCapClient initializes a client that keeps the quantity azure accepts of each dimension in a UTC month
+// below the cap of the dimension, truncating, skipping or holding the events that would exceed it by the policy.
+// The quantities accepted before are read from the ledger, so the caps survive restarts.
+func NewCapClient(client FanOutClient, config Configuration, ledger Ledger, logger logging.Logger) FanOutClient {
+	c := &capClient{
+		client:      client,
+		resourceURI: config.ResourceUri,
+		alerter:     config.alerter(logger),
+		logger:      logger,
+		config:      config,
+		accepted:    map[capKey]float64{},
+		pending:     map[capKey]float64{},
+		exceeded:    map[capKey]bool{},
+	}
+
+	for _, event := range ledger.Entries(time.Time{}, time.Now().AddDate(1, 0, 0)) {
+		if EstimateSourceAccepted.counts(event) {
+			c.accepted[newCapKey(event.UsageEvent)] += float64(event.Quantity)
+		}
+	}
+
+	return c
+}
+
+// CreateUsageEvent sends the event with the quantity below the cap of its dimension
+func (c *capClient) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (UsageEventResponse, error) {
+	admission := c.admit(ctx, event)
+	if admission.response != nil {
+		return *admission.response, nil
+	}
+
+	response, err := c.client.CreateUsageEvent(ctx, admission.event)
+	c.settle(ctx, admission, response, err)
+	if err == nil && response.Reason == "" {
+		response.Reason = admission.reason
+	}
+
+	return response, err
+}
+
+// BatchCreateUsageEvent sends the events of the batch with the quantities below the caps of their dimensions,
+// the results are aligned with the order of the requested events
+func (c *capClient) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*UsageEventBatchResponse, error) {
+	admissions := make([]capAdmission, len(batch.Events))
+	sent := []int{}
+	admitted := coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{}}
+	for i, event := range batch.Events {
+		admissions[i] = c.admit(ctx, event)
+		if admissions[i].response == nil {
+			sent = append(sent, i)
+			admitted.Events = append(admitted.Events, admissions[i].event)
+		}
+	}
+
+	var response *UsageEventBatchResponse
+	var err error
+	if len(admitted.Events) > 0 {
+		response, err = c.client.BatchCreateUsageEvent(ctx, admitted)
+	}
+
+	if err != nil {
+		for _, i := range sent {
+			c.settle(ctx, admissions[i], UsageEventResponse{}, err)
+		}
+		return nil, err
+	}
+
+	results := make([]UsageEventResponse, len(batch.Events))
+	for i, admission := range admissions {
+		if admission.response != nil {
+			results[i] = *admission.response
+		}
+	}
+	for j, i := range sent {
+		result := UsageEventResponse{}
+		if response != nil && j < len(response.Result) {
+			result = response.Result[j]
+		}
+		c.settle(ctx, admissions[i], result, nil)
+
+		if result.Reason == "" {
+			result.Reason = admissions[i].reason
+		}
+		results[i] = result
+	}
+
+	return &UsageEventBatchResponse{Result: results}, nil
+}
+
+// Reload applies the caps, the policy and the alert thresholds, and forwards the configuration to the client.
+// The usage of the dimensions whose cap changed alerts again once it exceeds the new cap, and the held events
+// are sent again in the background.
+func (c *capClient) Reload(config Configuration) {
+	c.mu.Lock()
+	for key := range c.exceeded {
+		previous, _ := c.config.CapOf(key.dimensionID)
+		if current, _ := config.CapOf(key.dimensionID); current != previous {
+			delete(c.exceeded, key)
+		}
+	}
+	c.config.DimensionCaps = config.DimensionCaps
+	c.config.CapPolicy = config.CapPolicy
+	c.config.CapAlertThresholds = config.CapAlertThresholds
+	c.mu.Unlock()
+
+	if reloadable, ok := c.client.(Reloadable); ok {
+		reloadable.Reload(config)
+	}
+
+	c.releases.Add(1)
+	go func() {
+		defer c.releases.Done()
+		c.release()
+	}()
+}
+
+// Close waits for the held events being sent and closes the client, the events still held are dropped
+func (c *capClient) Close(ctx context.Context) error {
+	c.releases.Wait()
+
+	c.mu.Lock()
+	for _, held := range c.held {
+		c.logger.Warnf("held metric '%s' = %v started at %s dropped, the adapter is stopping",
+			held.event.DimensionID, held.event.Quantity, held.event.StartAt.UTC().Format(time.RFC3339))
+	}
+	c.held = nil
+	c.mu.Unlock()
+
+	return c.client.Close(ctx)
+}
+
+// admit reserves the quantity of the event that remains below the cap of its dimension.
+// The events that exceed the cap are truncated to what remains, or skipped or held by the policy.
+func (c *capClient) admit(ctx context.Context, event coreMetering.UsageEvent) capAdmission {
+	admission, alerts := c.reserve(ctx, event)
+	c.notify(ctx, alerts)
+	return admission
+}
+
+func (c *capClient) reserve(ctx context.Context, event coreMetering.UsageEvent) (capAdmission, []Alert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	admission := capAdmission{event: event, key: newCapKey(event), reserved: float64(event.Quantity)}
+
+	limit, ok := c.config.CapOf(event.DimensionID)
+	if !ok {
+		return admission, nil
+	}
+
+	key := admission.key
+	remaining := limit - c.accepted[key] - c.pending[key]
+	if admission.reserved <= remaining {
+		c.pending[key] += admission.reserved
+		admission.capped = true
+		return admission, nil
+	}
+
+	alerts := []Alert{}
+	if !c.exceeded[key] {
+		c.exceeded[key] = true
+		alerts = append(alerts, c.newAlert(AlertCapExceeded, key, limit, 0,
+			fmt.Sprintf("usage of dimension '%s' exceeds the cap %v of %s, the events are %s",
+				key.dimensionID, limit, key.month.Format("2006-01"), c.config.CapPolicy.verb())))
+	}
+
+	if c.config.CapPolicy == CapPolicyTruncate && remaining > 0 {
+		admission.event.Quantity = float32(remaining)
+		admission.reserved = float64(admission.event.Quantity)
+		admission.reason = fmt.Sprintf("quantity %v truncated to %v by the cap %v of dimension '%s'",
+			event.Quantity, admission.event.Quantity, limit, key.dimensionID)
+		c.pending[key] += admission.reserved
+		admission.capped = true
+		return admission, alerts
+	}
+
+	status := StatusSkipped
+	if c.config.CapPolicy == CapPolicyHold {
+		status = StatusHeld
+		alerts = append(alerts, c.expire(time.Now())...)
+		c.held = append(c.held, heldEvent{tenantID: TenantFromContext(ctx), event: event})
+	}
+
+	reason := fmt.Sprintf("quantity %v exceeds the remaining %v of the cap %v of dimension '%s'",
+		event.Quantity, max(remaining, 0), limit, key.dimensionID)
+	correlation.Logger(ctx, c.logger).Warnf("metric '%s' %s = %v, %s",
+		event.DimensionID, strings.ToLower(status), event.Quantity, reason)
+
+	admission.response = &UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{DimensionID: event.DimensionID, Status: status},
+		Reason:             reason,
+	}
+	return admission, alerts
+}
+
+// expire drops the held events azure would reject, returning their alerts. It must be called with the lock held.
+func (c *capClient) expire(now time.Time) []Alert {
+	alerts := []Alert{}
+	held := c.held[:0]
+	for _, h := range c.held {
+		if now.Sub(h.event.StartAt) <= capHoldWindow {
+			held = append(held, h)
+			continue
+		}
+
+		alerts = append(alerts, Alert{
+			Kind:        AlertEventExpired,
+			TenantID:    h.tenantID,
+			ResourceURI: c.resourceURI,
+			DimensionID: h.event.DimensionID,
+			Status:      StatusHeld,
+			Message: fmt.Sprintf("held usage event of dimension '%s' started at %s expired, quantity %v wasn't billed",
+				h.event.DimensionID, h.event.StartAt.UTC().Format(time.RFC3339), h.event.Quantity),
+			Time: now.UTC(),
+		})
+	}
+	c.held = held
+	return alerts
+}
+
+// release sends the held events again, the ones that still exceed the caps are held again by the policy,
+// and the ones that couldn't be sent are held until the next reload
+func (c *capClient) release() {
+	c.mu.Lock()
+	alerts := c.expire(time.Now())
+	held := c.held
+	c.held = nil
+	c.mu.Unlock()
+
+	c.notify(context.Background(), alerts)
+
+	for _, h := range held {
+		ctx := WithTenant(context.Background(), h.tenantID)
+		response, err := c.CreateUsageEvent(ctx, h.event)
+		if err != nil {
+			c.logger.Warnf("held metric '%s' = %v couldn't be sent, it is held until the next reload. Err: %v",
+				h.event.DimensionID, h.event.Quantity, err)
+
+			c.mu.Lock()
+			c.held = append(c.held, h)
+			c.mu.Unlock()
+			continue
+		}
+
+		c.logger.Infof("held metric '%s' = %v released with status %s", h.event.DimensionID, h.event.Quantity,
+			response.Status)
+	}
+}
+
+// settle releases the quantity reserved for the event, and counts it when azure accepted it,
+// alerting of the thresholds of the cap it crossed
+func (c *capClient) settle(ctx context.Context, admission capAdmission, response UsageEventResponse, err error) {
+	c.notify(ctx, c.count(admission, err == nil && response.Status == StatusAccepted))
+}
+
+func (c *capClient) count(admission capAdmission, accepted bool) []Alert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := admission.key
+	if admission.capped {
+		c.pending[key] -= admission.reserved
+	}
+
+	// the cap may have been removed by a reload while the event was sent
+	limit, ok := c.config.CapOf(admission.event.DimensionID)
+	if !ok || !accepted {
+		return nil
+	}
+
+	before := c.accepted[key]
+	c.accepted[key] += admission.reserved
+
+	thresholds := append([]float64{}, c.config.CapAlertThresholds...)
+	sort.Float64s(thresholds)
+
+	alerts := []Alert{}
+	for _, threshold := range thresholds {
+		if quantity := limit * threshold / 100; before < quantity && quantity <= c.accepted[key] {
+			alerts = append(alerts, c.newAlert(AlertCapThreshold, key, limit, threshold,
+				fmt.Sprintf("usage of dimension '%s' reached %v%% of the cap %v of %s",
+					key.dimensionID, threshold, limit, key.month.Format("2006-01"))))
+		}
+	}
+	return alerts
+}
+
+// newAlert returns an alert of the cap of the month and dimension, it must be called with the lock held
+func (c *capClient) newAlert(kind AlertKind, key capKey, limit, threshold float64, message string) Alert {
+	return Alert{
+		Kind:        kind,
+		ResourceURI: c.resourceURI,
+		DimensionID: key.dimensionID,
+		Message:     message,
+		Time:        time.Now().UTC(),
+		Cap: &CapUsage{
+			Month:     key.month,
+			Cap:       limit,
+			Quantity:  c.accepted[key],
+			Threshold: threshold,
+			Policy:    c.config.CapPolicy,
+		},
+	}
+}
+
+// notify delivers the alerts outside of the lock, with the tenant of the context when they have none
+func (c *capClient) notify(ctx context.Context, alerts []Alert) {
+	for _, alert := range alerts {
+		if alert.TenantID == "" {
+			alert.TenantID = TenantFromContext(ctx)
+		}
+		c.alerter.Alert(ctx, alert)
+	}
+}
+
+func (p CapPolicy) verb() string {
+	switch p {
+	case CapPolicySkip:
+		return "skipped"
+	case CapPolicyHold:
+		return "held"
+	}
+	return "truncated"
+}
@@ -0,0 +1,274 @@
+package metering_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+type fakeAlerter struct {
+	mu     sync.Mutex
+	alerts []metering.Alert
+}
+
+func (a *fakeAlerter) Alert(_ context.Context, alert metering.Alert) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.alerts = append(a.alerts, alert)
+}
+
+// kinds returns the kind and threshold of the alerts, and forgets them
+func (a *fakeAlerter) kinds() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	kinds := []string{}
+	for _, alert := range a.alerts {
+		if alert.Cap == nil {
+			kinds = append(kinds, string(alert.Kind))
+			continue
+		}
+		kinds = append(kinds, fmt.Sprintf("%s %v", alert.Kind, alert.Cap.Threshold))
+	}
+	a.alerts = nil
+	return kinds
+}
+
+func accepted(event coreMetering.UsageEvent) metering.UsageEventResponse {
+	return metering.UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{DimensionID: event.DimensionID, Status: metering.StatusAccepted},
+	}
+}
+
+func TestCapClient(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	startAt := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
+	gpu := func(quantity float32) coreMetering.UsageEvent {
+		return coreMetering.UsageEvent{DimensionID: "gpu", Quantity: quantity, StartAt: startAt}
+	}
+
+	// the ledger already has 70 of the cap of 100 accepted in the month, and usage of the month before
+	newClient := func(t *testing.T, primary metering.Client, policy metering.CapPolicy, alerter metering.Alerter) metering.Client {
+		ledger, err := metering.NewLedger("", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ledger.Send(context.Background(), []metering.SinkEvent{
+			{UsageEvent: gpu(70), Status: metering.StatusAccepted},
+			{UsageEvent: gpu(10), Status: "Failed"},
+			{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 90, StartAt: startAt.AddDate(0, -1, 0)},
+				Status: metering.StatusAccepted},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		configuration := metering.Configuration{
+			DimensionCaps:      map[string]float64{"gpu": 100},
+			CapPolicy:          policy,
+			CapAlertThresholds: []float64{100, 80},
+			Alerter:            alerter,
+		}
+		fanOut := metering.NewFanOutClient(primary, nil, metering.SinkConfiguration{}, logger)
+		return metering.NewCapClient(fanOut, configuration, ledger, logger)
+	}
+
+	t.Run("truncates the usage above the cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		primary := mock.NewMockMeteringClient(ctrl)
+		gomock.InOrder(
+			primary.EXPECT().CreateUsageEvent(gomock.Any(), gpu(20)).Return(accepted(gpu(20)), nil),
+			primary.EXPECT().CreateUsageEvent(gomock.Any(), gpu(10)).Return(accepted(gpu(10)), nil),
+		)
+
+		alerter := &fakeAlerter{}
+		client := newClient(t, primary, metering.CapPolicyTruncate, alerter)
+		ctx := metering.WithTenant(context.Background(), "contoso")
+
+		response, err := client.CreateUsageEvent(ctx, gpu(20))
+		if err != nil || response.Status != metering.StatusAccepted {
+			t.Fatalf("expected the event to be accepted, got %+v %v", response, err)
+		}
+		if diff := cmp.Diff([]string{"capThreshold 80"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		response, err = client.CreateUsageEvent(ctx, gpu(30))
+		if err != nil || response.Status != metering.StatusAccepted || response.Reason == "" {
+			t.Fatalf("expected the event to be truncated, got %+v %v", response, err)
+		}
+		if diff := cmp.Diff([]string{"capExceeded 0", "capThreshold 100"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		response, err = client.CreateUsageEvent(ctx, gpu(5))
+		if err != nil || response.Status != metering.StatusSkipped {
+			t.Fatalf("expected the event to be skipped, got %+v %v", response, err)
+		}
+		if kinds := alerter.kinds(); len(kinds) != 0 {
+			t.Fatalf("expected the alerts not to repeat, got %v", kinds)
+		}
+	})
+
+	t.Run("skips the usage above the cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cpu := coreMetering.UsageEvent{DimensionID: "cpu", Quantity: 1000, StartAt: startAt}
+		primary := mock.NewMockMeteringClient(ctrl)
+		primary.EXPECT().
+			BatchCreateUsageEvent(gomock.Any(), coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{gpu(20), cpu}}).
+			Return(&metering.UsageEventBatchResponse{Result: []metering.UsageEventResponse{accepted(gpu(20)), accepted(cpu)}}, nil)
+
+		alerter := &fakeAlerter{}
+		client := newClient(t, primary, metering.CapPolicySkip, alerter)
+
+		response, err := client.BatchCreateUsageEvent(context.Background(),
+			coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{gpu(20), gpu(50), cpu}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statuses := []string{}
+		for _, result := range response.Result {
+			statuses = append(statuses, result.DimensionID+" "+result.Status)
+		}
+		if diff := cmp.Diff([]string{"gpu Accepted", "gpu Skipped", "cpu Accepted"}, statuses); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"capExceeded 0", "capThreshold 80"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("applies the reloaded caps", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		primary := mock.NewMockMeteringClient(ctrl)
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), gpu(50)).Return(accepted(gpu(50)), nil)
+
+		alerter := &fakeAlerter{}
+		client := newClient(t, primary, metering.CapPolicySkip, alerter)
+
+		if response, err := client.CreateUsageEvent(context.Background(), gpu(50)); err != nil ||
+			response.Status != metering.StatusSkipped {
+			t.Fatalf("expected the event to be skipped, got %+v %v", response, err)
+		}
+		if diff := cmp.Diff([]string{"capExceeded 0"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		client.(metering.Reloadable).Reload(metering.Configuration{
+			DimensionCaps:      map[string]float64{"gpu": 200},
+			CapPolicy:          metering.CapPolicySkip,
+			CapAlertThresholds: []float64{100, 80},
+		})
+
+		if response, err := client.CreateUsageEvent(context.Background(), gpu(50)); err != nil ||
+			response.Status != metering.StatusAccepted {
+			t.Fatalf("expected the event to be accepted below the new cap, got %+v %v", response, err)
+		}
+		if kinds := alerter.kinds(); len(kinds) != 0 {
+			t.Fatalf("expected no alert below the thresholds of the new cap, got %v", kinds)
+		}
+	})
+
+	t.Run("releases the reservations of the caps removed while sending", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		alerter := &fakeAlerter{}
+		primary := mock.NewMockMeteringClient(ctrl)
+		var client metering.Client
+		gomock.InOrder(
+			primary.EXPECT().CreateUsageEvent(gomock.Any(), gpu(20)).DoAndReturn(
+				func(context.Context, coreMetering.UsageEvent) (metering.UsageEventResponse, error) {
+					client.(metering.Reloadable).Reload(metering.Configuration{CapPolicy: metering.CapPolicyTruncate})
+					return metering.UsageEventResponse{}, fmt.Errorf("unavailable")
+				}),
+			primary.EXPECT().CreateUsageEvent(gomock.Any(), gpu(30)).Return(accepted(gpu(30)), nil),
+		)
+
+		client = newClient(t, primary, metering.CapPolicyTruncate, alerter)
+
+		if _, err := client.CreateUsageEvent(context.Background(), gpu(20)); err == nil {
+			t.Fatal("expected the error of the client")
+		}
+
+		client.(metering.Reloadable).Reload(metering.Configuration{
+			DimensionCaps:      map[string]float64{"gpu": 100},
+			CapPolicy:          metering.CapPolicyTruncate,
+			CapAlertThresholds: []float64{100, 80},
+		})
+
+		response, err := client.CreateUsageEvent(context.Background(), gpu(30))
+		if err != nil || response.Status != metering.StatusAccepted || response.Reason != "" {
+			t.Fatalf("expected the event to be accepted without truncation, got %+v %v", response, err)
+		}
+	})
+
+	t.Run("holds the usage above the cap until the caps are reloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// azure still accepts the recent event once the cap is raised, but not the one of the ledger's month
+		recent := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 150, StartAt: time.Now().UTC().Truncate(time.Hour)}
+		released := make(chan struct{})
+		primary := mock.NewMockMeteringClient(ctrl)
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), recent).DoAndReturn(
+			func(context.Context, coreMetering.UsageEvent) (metering.UsageEventResponse, error) {
+				defer close(released)
+				return accepted(recent), nil
+			})
+
+		alerter := &fakeAlerter{}
+		client := newClient(t, primary, metering.CapPolicyHold, alerter)
+		ctx := metering.WithTenant(context.Background(), "contoso")
+
+		for _, event := range []coreMetering.UsageEvent{recent, gpu(50)} {
+			response, err := client.CreateUsageEvent(ctx, event)
+			if err != nil || response.Status != metering.StatusHeld || response.Reason == "" {
+				t.Fatalf("expected the event to be held, got %+v %v", response, err)
+			}
+		}
+		if diff := cmp.Diff([]string{"capExceeded 0", "capExceeded 0"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		client.(metering.Reloadable).Reload(metering.Configuration{
+			DimensionCaps:      map[string]float64{"gpu": 200},
+			CapPolicy:          metering.CapPolicyHold,
+			CapAlertThresholds: []float64{100, 80},
+		})
+
+		select {
+		case <-released:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the held event to be sent once the cap was raised")
+		}
+		if diff := cmp.Diff([]string{"eventExpired"}, alerter.kinds()); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
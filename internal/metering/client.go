@@ -6,14 +6,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	armruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/ydataai/go-core/pkg/common/logging"
 	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/logs"
 )
 
 type apiPath string
@@ -28,121 +34,171 @@ const (
 	baseURI    = "https://marketplaceapi.microsoft.com/api"
 )
 
-// Client defines a struct with required dependencies for metering client
-type Client struct {
-	config Configuration
-	logger logging.Logger
-	pl     runtime.Pipeline
+// redactor logs the headers of the responses without the sensitive ones
+var redactor = logs.NewRedactor(nil)
+
+// Endpoint is the host of the marketplace metering API, which readiness checks use to verify billing works
+const Endpoint = "https://marketplaceapi.microsoft.com"
+
+// TokenScope is the scope of the tokens the client requests, which readiness checks use to verify billing works.
+// The pipeline uses the resource manager audience of the public cloud.
+var TokenScope = cloud.AzurePublic.Services[cloud.ResourceManager].Audience + "/.default"
+
+// Client defines an interface for metering client
+type Client interface {
+	CreateUsageEvent(context.Context, coreMetering.UsageEvent) (UsageEventResponse, error)
+	BatchCreateUsageEvent(context.Context, coreMetering.UsageEventBatch) (*UsageEventBatchResponse, error)
+}
+
+// Reloadable defines the clients that apply the safe fields of a new configuration without a restart
+type Reloadable interface {
+	Reload(Configuration)
+}
+
+// marketplaceClient defines a struct with required dependencies for metering client
+type marketplaceClient struct {
+	mu       *sync.RWMutex
+	config   *Configuration
+	logger   logging.Logger
+	pl       runtime.Pipeline
+	endpoint string
 }
 
 // NewClient initializes metering client
 func NewClient(
 	credential azcore.TokenCredential, config Configuration, logger logging.Logger,
 ) (Client, error) {
-	pl, err := armruntime.NewPipeline("marketplace", "v0.1.0", credential, runtime.PipelineOptions{}, nil)
+	return newClient(credential, config, logger, baseURI, nil)
+}
+
+func newClient(
+	credential azcore.TokenCredential,
+	config Configuration,
+	logger logging.Logger,
+	endpoint string,
+	transport policy.Transporter,
+) (Client, error) {
+	pl, err := armruntime.NewPipeline("marketplace", "v0.1.0", credential, runtime.PipelineOptions{}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Transport:       transport,
+			PerCallPolicies: []policy.Policy{correlation.NewPolicy()},
+		},
+	})
 	if err != nil {
-		return Client{}, err
+		return nil, err
 	}
 
-	return Client{
-		config: config,
-		logger: logger,
-		pl:     pl,
+	return marketplaceClient{
+		mu:       &sync.RWMutex{},
+		config:   &config,
+		logger:   logger,
+		pl:       pl,
+		endpoint: endpoint,
 	}, nil
 }
 
 // CreateUsageEvent creates and sends a request to create an UsageEvent
-// It returns an error if any or an UsageEventResponse from azure
-func (c Client) CreateUsageEvent(
+// It returns an error if any or an UsageEventResponse from azure, or with a Skipped status when it wasn't sent
+func (c marketplaceClient) CreateUsageEvent(
 	ctx context.Context, event coreMetering.UsageEvent,
-) (coreMetering.UsageEventResponse, error) {
-	c.logger.Infof("received create event with %+v", event)
-
-	if event.Quantity <= 0 {
-		c.logger.Infof("metric '%s' skipped (%s <-> %s) = %v",
-			event.DimensionID,
-			event.StartAt.Format(TimeLayout),
-			time.Now().Format(TimeLayout),
-			event.Quantity,
-		)
-		return coreMetering.UsageEventResponse{}, nil
+) (UsageEventResponse, error) {
+	logger := correlation.Logger(ctx, c.logger)
+	logger.Infof("received create event of dimension '%s' = %v at %s",
+		event.DimensionID, event.Quantity, event.StartAt.Format(TimeLayout))
+
+	config := c.configuration()
+	if reason, skip := config.SkipReason(event.DimensionID, event.Quantity); skip {
+		return c.skippedResponse(logger, event, reason), nil
 	}
 
-	azevent := usageEvent{
-		Dimension:          event.DimensionID,
-		Quantity:           event.Quantity,
-		EffectiveStartTime: event.StartAt,
-		ResourceURI:        c.config.ResourceUri,
-		PlanID:             c.config.PlanId,
-	}
+	azevent := transform(config, event)
 
-	c.logger.Infof("event transformed into %+v", azevent)
+	logger.Infof("event of dimension '%s' billed against plan '%s' at %s",
+		azevent.Dimension, azevent.PlanID, azevent.EffectiveStartTime.Format(TimeLayout))
 
-	req, err := createRequest(ctx, usageEventAPIPath, azevent)
+	req, err := c.createRequest(ctx, usageEventAPIPath, azevent)
 	if err != nil {
-		return coreMetering.UsageEventResponse{}, err
+		return UsageEventResponse{}, err
 	}
 
 	resp, err := c.pl.Do(req)
 	if err != nil {
-		return coreMetering.UsageEventResponse{}, err
+		return UsageEventResponse{}, err
 	}
 
-	c.logger.Infof("got response %+v", resp)
+	// the response is logged without its request, whose headers have the token
+	logger.Infof("got response %s", redactor.Response(resp))
 	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Errorf("failed to decode body with error %v", err)
+		logger.Errorf("failed to decode body with error %v", err)
 	}
 
-	c.logger.Info("body: ", string(bytes))
+	logger.Info("body: ", string(bytes))
 
 	if !runtime.HasStatusCode(resp, http.StatusOK, http.StatusCreated) {
-		return coreMetering.UsageEventResponse{}, invalidStatusCodeError(resp)
+		return UsageEventResponse{}, invalidStatusCodeError(resp)
 	}
 
 	eventResponse := usageEventResponse{}
 	if err := runtime.UnmarshalAsJSON(resp, &eventResponse); err != nil {
-		return coreMetering.UsageEventResponse{}, err
+		return UsageEventResponse{}, err
 	}
 
-	c.logger.Infof("unmarshelled into event %+v", eventResponse)
+	logger.Infof("usage event %s of dimension '%s' is %s",
+		eventResponse.UsageEventId, eventResponse.Dimension, eventResponse.Status)
 
-	return coreMetering.UsageEventResponse{
-		UsageEventID: eventResponse.UsageEventId,
-		DimensionID:  eventResponse.Dimension,
-		Status:       eventResponse.Status,
-	}, nil
+	return eventResponse.toUsageEventResponse(), nil
 }
 
 // BatchCreateUsageEvent creates a batch of UsageEvent with azure APIs
-// It returns an error if any or an UsageEventResponse from azure
-func (c Client) BatchCreateUsageEvent(
+// It returns an error if any or an UsageEventBatchResponse with one result for each event of the batch,
+// in the same order, where the events that weren't sent have a Skipped status
+func (c marketplaceClient) BatchCreateUsageEvent(
 	ctx context.Context, batch coreMetering.UsageEventBatch,
-) (*coreMetering.UsageEventBatchResponse, error) {
+) (*UsageEventBatchResponse, error) {
+	logger := correlation.Logger(ctx, c.logger)
+	results := make([]UsageEventResponse, len(batch.Events))
 	events := []usageEvent{}
+	sent := []int{}
+	hours := map[string]int{}
+	config := c.configuration()
 
-	for _, request := range batch.Events {
-		if request.Quantity <= 0 {
-			c.logger.Infof("metric '%s' skipped (%s <-> %s) = %v",
-				request.DimensionID,
-				request.StartAt.Format(TimeLayout),
-				time.Now().Format(TimeLayout),
-				request.Quantity,
-			)
+	for i, request := range batch.Events {
+		if reason, skip := config.SkipReason(request.DimensionID, request.Quantity); skip {
+			results[i] = c.skippedResponse(logger, request, reason)
 			continue
 		}
 
-		event := usageEvent{
-			Dimension:          request.DimensionID,
-			Quantity:           request.Quantity,
-			EffectiveStartTime: request.StartAt,
-			ResourceURI:        c.config.ResourceUri,
-			PlanID:             c.config.PlanId,
+		event := transform(config, request)
+
+		key := batchResultKey(event.Dimension, event.EffectiveStartTime.Truncate(time.Hour))
+		if first, ok := hours[key]; ok {
+			reason := fmt.Sprintf("event %d collapses onto the same dimension and hour as event %d", i, first)
+			if config.DuplicatePolicy == DuplicatePolicyReject {
+				logger.Errorf("metric '%s' rejected, %s", event.Dimension, reason)
+				results[i] = UsageEventResponse{
+					UsageEventResponse: coreMetering.UsageEventResponse{
+						DimensionID: event.Dimension,
+						Status:      StatusRejected,
+					},
+					Reason: reason,
+				}
+				continue
+			}
+			logger.Warnf("metric '%s' %s", event.Dimension, reason)
+		} else {
+			hours[key] = i
 		}
+
 		events = append(events, event)
+		sent = append(sent, i)
+	}
+
+	if len(events) == 0 {
+		return &UsageEventBatchResponse{Result: results}, nil
 	}
 
-	req, err := createRequest(ctx, batchUsageEventAPIPath, usageEventBatch{Events: events})
+	req, err := c.createRequest(ctx, batchUsageEventAPIPath, usageEventBatch{Events: events})
 	if err != nil {
 		return nil, err
 	}
@@ -158,26 +214,127 @@ func (c Client) BatchCreateUsageEvent(
 
 	result := &usageEventBatchResponse{}
 	if err := runtime.UnmarshalAsJSON(resp, result); err != nil {
-		return &coreMetering.UsageEventBatchResponse{}, err
+		return &UsageEventBatchResponse{}, err
 	}
 
-	results := []coreMetering.UsageEventResponse{}
 	for _, result := range result.Result {
 		if len(result.Error.Details) > 0 {
-			c.logger.Errorf("Failed to process event batch %v.", result.Error.Details)
+			logger.Errorf("Failed to process event batch %v.", result.Error.Details)
+		}
+	}
+
+	for i, response := range alignBatchResults(events, result.Result) {
+		results[sent[i]] = response
+	}
+
+	return &UsageEventBatchResponse{Result: results}, nil
+}
+
+// Reload applies the skip thresholds, the start time truncation and the duplicate policy of the configuration,
+// the requests in-flight keep the configuration they started with
+func (c marketplaceClient) Reload(config Configuration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.config.SkipThreshold = config.SkipThreshold
+	c.config.DimensionSkipThresholds = config.DimensionSkipThresholds
+	c.config.TruncateStartTime = config.TruncateStartTime
+	c.config.DuplicatePolicy = config.DuplicatePolicy
+}
+
+func (c marketplaceClient) configuration() Configuration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return *c.config
+}
+
+// transform converts an usage event into an azure usage event, normalizing the effective start time
+// and billing it against the plan of that time
+func transform(config Configuration, event coreMetering.UsageEvent) usageEvent {
+	startAt := config.NormalizeStartTime(event.StartAt)
+	azevent := usageEvent{
+		Dimension:          event.DimensionID,
+		Quantity:           event.Quantity,
+		EffectiveStartTime: startAt,
+		PlanID:             config.PlanAt(startAt),
+	}
+
+	if config.OfferType == OfferTypeSaaS {
+		azevent.ResourceID = config.ResourceUri
+	} else {
+		azevent.ResourceURI = config.ResourceUri
+	}
+
+	return azevent
+}
+
+func (c marketplaceClient) skippedResponse(
+	logger logging.Logger, event coreMetering.UsageEvent, reason string,
+) UsageEventResponse {
+	logger.Infof("metric '%s' skipped (%s <-> %s) = %v, %s",
+		event.DimensionID,
+		event.StartAt.Format(TimeLayout),
+		time.Now().Format(TimeLayout),
+		event.Quantity,
+		reason,
+	)
+
+	return UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{
+			DimensionID: event.DimensionID,
+			Status:      StatusSkipped,
+		},
+		Reason: reason,
+	}
+}
+
+// alignBatchResults returns the azure results in the same order as the sent events.
+// Azure answers in the request order, but when the number of results differs they are matched by
+// dimension and effective start time.
+func alignBatchResults(events []usageEvent, results []usageEventResponse) []UsageEventResponse {
+	aligned := make([]UsageEventResponse, len(events))
+
+	if len(results) == len(events) {
+		for i, result := range results {
+			aligned[i] = result.toUsageEventResponse()
 		}
-		event := coreMetering.UsageEventResponse{
-			UsageEventID: result.UsageEventId,
-			DimensionID:  result.Dimension,
-			Status:       result.Status,
+		return aligned
+	}
+
+	pending := map[string][]usageEventResponse{}
+	for _, result := range results {
+		key := batchResultKey(result.Dimension, result.EffectiveStartTime)
+		pending[key] = append(pending[key], result)
+	}
+
+	for i, event := range events {
+		key := batchResultKey(event.Dimension, event.EffectiveStartTime)
+		matches := pending[key]
+		if len(matches) == 0 {
+			aligned[i] = UsageEventResponse{
+				UsageEventResponse: coreMetering.UsageEventResponse{
+					DimensionID: event.Dimension,
+					Status:      StatusUnknown,
+				},
+				Reason: "azure didn't return a result for this event",
+			}
+			continue
 		}
-		results = append(results, event)
+
+		aligned[i] = matches[0].toUsageEventResponse()
+		pending[key] = matches[1:]
 	}
-	return &coreMetering.UsageEventBatchResponse{Result: results}, nil
+
+	return aligned
+}
+
+func batchResultKey(dimension string, startAt time.Time) string {
+	return fmt.Sprintf("%s/%d", dimension, startAt.Unix())
 }
 
-func createRequest(ctx context.Context, path apiPath, event interface{}) (*policy.Request, error) {
-	req, err := runtime.NewRequest(ctx, http.MethodPost, runtime.JoinPaths(baseURI, string(path)))
+func (c marketplaceClient) createRequest(ctx context.Context, path apiPath, event interface{}) (*policy.Request, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodPost, runtime.JoinPaths(c.endpoint, string(path)))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,166 @@
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+// tokenCredential records the scopes of the requested tokens
+type tokenCredential struct {
+	scopes *[]string
+}
+
+func (c tokenCredential) GetToken(_ context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if c.scopes != nil {
+		*c.scopes = append(*c.scopes, options.Scopes...)
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestTokenScope(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(usageEventResponse{Status: StatusAccepted, Dimension: "gpu"})
+	}))
+	defer server.Close()
+
+	scopes := []string{}
+	client, err := newClient(tokenCredential{scopes: &scopes}, Configuration{}, logger, server.URL+"/api", server.Client())
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: time.Now().Add(-time.Hour)}
+	if _, err := client.CreateUsageEvent(context.Background(), event); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	// the readiness check must request the same token as the billing pipeline
+	if diff := cmp.Diff([]string{TokenScope}, scopes); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchWithSkippedEvents(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+	startAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// serve answers with the results of the sent events, in the order of the dimensions when there are any
+	serve := func(t *testing.T, order ...string) (*httptest.Server, *[]string) {
+		sent := []string{}
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/batchUsageEvent" || r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			batch := usageEventBatch{}
+			if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+				t.Error(err)
+			}
+
+			results := map[string]usageEventResponse{}
+			for _, event := range batch.Events {
+				sent = append(sent, event.Dimension)
+				results[event.Dimension] = usageEventResponse{
+					UsageEventId:       "id-" + event.Dimension,
+					Status:             StatusAccepted,
+					Dimension:          event.Dimension,
+					EffectiveStartTime: event.EffectiveStartTime,
+				}
+			}
+			if len(order) == 0 {
+				order = sent
+			}
+
+			response := usageEventBatchResponse{}
+			for _, dimension := range order {
+				response.Result = append(response.Result, results[dimension])
+			}
+			response.Count = len(response.Result)
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+		return server, &sent
+	}
+
+	batch := coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{
+		{DimensionID: "gpu", Quantity: 0.5, StartAt: startAt},
+		{DimensionID: "cpu", Quantity: 2, StartAt: startAt},
+		{DimensionID: "ram", Quantity: -1, StartAt: startAt},
+		{DimensionID: "disk", Quantity: 3, StartAt: startAt},
+	}}
+	configuration := Configuration{DimensionSkipThresholds: map[string]float32{"gpu": 0.5}}
+
+	tt := []struct {
+		name     string
+		order    []string
+		expected []string
+	}{
+		{
+			name:     "results in the request order",
+			expected: []string{"gpu:" + StatusSkipped, "cpu:" + StatusAccepted, "ram:" + StatusSkipped, "disk:" + StatusAccepted},
+		},
+		{
+			name:     "fewer results matched by dimension",
+			order:    []string{"disk"},
+			expected: []string{"gpu:" + StatusSkipped, "cpu:" + StatusUnknown, "ram:" + StatusSkipped, "disk:" + StatusAccepted},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			server, sent := serve(t, tc.order...)
+			defer server.Close()
+
+			client, err := newClient(tokenCredential{}, configuration, logger, server.URL+"/api", server.Client())
+			if err != nil {
+				t.Fatalf("should not return any error, got %v", err)
+			}
+
+			response, err := client.BatchCreateUsageEvent(context.Background(), batch)
+			if err != nil {
+				t.Fatalf("should not return any error, got %v", err)
+			}
+
+			if diff := cmp.Diff([]string{"cpu", "disk"}, *sent); diff != "" {
+				t.Fatalf("sent events mismatch (-want +got):\n%s", diff)
+			}
+
+			results := []string{}
+			for _, result := range response.Result {
+				results = append(results, result.DimensionID+":"+result.Status)
+			}
+			if diff := cmp.Diff(tc.expected, results); diff != "" {
+				t.Fatalf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
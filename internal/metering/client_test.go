@@ -0,0 +1,84 @@
+package metering_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestSkippedUsageEvents(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	configuration := metering.Configuration{
+		SkipThreshold:           0,
+		DimensionSkipThresholds: map[string]float32{"gpu": 0.5},
+	}
+
+	client, err := metering.NewClient(fakeCredential{}, configuration, logger)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	startAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	t.Run("single event", func(t *testing.T) {
+		response, err := client.CreateUsageEvent(ctx, coreMetering.UsageEvent{
+			DimensionID: "cpu", Quantity: 0, StartAt: startAt,
+		})
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		if response.Status != metering.StatusSkipped || response.Reason == "" {
+			t.Fatalf("should be skipped with a reason, got %+v", response)
+		}
+	})
+
+	t.Run("batch keeps the order of the events", func(t *testing.T) {
+		response, err := client.BatchCreateUsageEvent(ctx, coreMetering.UsageEventBatch{
+			Events: []coreMetering.UsageEvent{
+				{DimensionID: "gpu", Quantity: 0.5, StartAt: startAt},
+				{DimensionID: "cpu", Quantity: -1, StartAt: startAt},
+				{DimensionID: "ram", Quantity: 0, StartAt: startAt},
+			},
+		})
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		dimensions := []string{}
+		for _, result := range response.Result {
+			if result.Status != metering.StatusSkipped {
+				t.Fatalf("should be skipped, got %+v", result)
+			}
+			dimensions = append(dimensions, result.DimensionID)
+		}
+
+		if diff := cmp.Diff([]string{"gpu", "cpu", "ram"}, dimensions); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -1,18 +1,192 @@
 // Package metering provides objects to interact with metering API
 package metering
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
 
 // TimeLayout ISO time layout
 const TimeLayout = "2006-01-02T15:04:05.000Z"
 
+// DuplicatePolicy defines what to do when two events of a batch collapse onto the same dimension and hour
+type DuplicatePolicy string
+
+// Supported duplicate policies
+const (
+	DuplicatePolicyWarn   DuplicatePolicy = "warn"
+	DuplicatePolicyReject DuplicatePolicy = "reject"
+)
+
+// CapPolicy defines what to do with the usage events that would exceed the monthly cap of their dimension
+type CapPolicy string
+
+// Supported cap policies
+const (
+	// CapPolicyTruncate sends the quantity that remains below the cap, the excess is never billed
+	CapPolicyTruncate CapPolicy = "truncate"
+	// CapPolicySkip doesn't send the events that would exceed the cap, which are skipped and never billed
+	CapPolicySkip CapPolicy = "skip"
+	// CapPolicyHold keeps the events that would exceed the cap, and sends them once the caps are reloaded
+	// and they fit the cap. Since azure rejects the usage older than 24 hours, the events held longer are dropped
+	// with an alert, and so are the ones held when the adapter stops.
+	CapPolicyHold CapPolicy = "hold"
+)
+
+// OfferType defines the kind of marketplace offer, which determines how the resource is identified
+type OfferType string
+
+// Supported offer types
+const (
+	// OfferTypeManagedApplication identifies the resource by the resource URI of the managed application
+	OfferTypeManagedApplication OfferType = "managedApplication"
+	// OfferTypeSaaS identifies the resource by the id of the SaaS subscription
+	OfferTypeSaaS OfferType = "saas"
+)
+
 // Configuration represents the configuration for metering client.
+// The resource may be empty when the usage is only sent on behalf of the tenants of the registry.
 type Configuration struct {
-	ResourceUri string `envconfig:"MANAGED_APP_RESOURCE_URI" required:"true"`
-	PlanId      string `envconfig:"MANAGED_APP_PLAN_ID" required:"true"`
+	ResourceUri             string             `envconfig:"MANAGED_APP_RESOURCE_URI" default:""`
+	PlanId                  string             `envconfig:"MANAGED_APP_PLAN_ID" default:""`
+	OfferType               OfferType          `envconfig:"METERING_OFFER_TYPE" default:"managedApplication"`
+	SkipThreshold           float32            `envconfig:"METERING_SKIP_THRESHOLD" default:"0"`
+	DimensionSkipThresholds map[string]float32 `envconfig:"METERING_DIMENSION_SKIP_THRESHOLDS" default:""`
+	TruncateStartTime       bool               `envconfig:"METERING_TRUNCATE_START_TIME" default:"false"`
+	DuplicatePolicy         DuplicatePolicy    `envconfig:"METERING_DUPLICATE_POLICY" default:"warn"`
+	PlanHistoryPath         string             `envconfig:"METERING_PLAN_HISTORY_PATH" default:""`
+	// DimensionCaps limits the quantity of each dimension billed in a UTC month, the other dimensions have no cap.
+	// CapAlertThresholds are the percentages of the caps that raise an alert once the usage crosses them.
+	DimensionCaps      map[string]float64 `envconfig:"METERING_DIMENSION_CAPS" default:""`
+	CapPolicy          CapPolicy          `envconfig:"METERING_CAP_POLICY" default:"truncate"`
+	CapAlertThresholds []float64          `envconfig:"METERING_CAP_ALERT_THRESHOLDS" default:"80,100"`
+	// RejectionAlertThreshold is the number of consecutive rejections of a dimension that raises an alert.
+	// The circuit breaker stops sending the usage for the cooldown after the number of consecutive failures,
+	// it is disabled when the number is zero.
+	RejectionAlertThreshold int           `envconfig:"METERING_REJECTION_ALERT_THRESHOLD" default:"3"`
+	CircuitBreakerFailures  int           `envconfig:"METERING_CIRCUIT_BREAKER_FAILURES" default:"0"`
+	CircuitBreakerCooldown  time.Duration `envconfig:"METERING_CIRCUIT_BREAKER_COOLDOWN" default:"1m"`
+
+	// Plans resolves the plan of the events from their start time, PlanId is the plan before any known change.
+	// The changes of plan are kept in the file of PlanHistoryPath, or in memory when it is empty.
+	Plans PlanResolver `ignored:"true"`
+	// Alerter receives the alerts of the caps, failures and rejections, they are only logged when it is nil
+	Alerter Alerter `ignored:"true"`
 }
 
 // LoadFromEnvVars reads all env vars required for the metering client.
 func (c *Configuration) LoadFromEnvVars() error {
-	return envconfig.Process("", c)
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	if (c.ResourceUri == "") != (c.PlanId == "") {
+		return fmt.Errorf("MANAGED_APP_RESOURCE_URI and MANAGED_APP_PLAN_ID must be set together")
+	}
+
+	if err := c.OfferType.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateCaps(); err != nil {
+		return err
+	}
+
+	if c.RejectionAlertThreshold < 0 || c.CircuitBreakerFailures < 0 {
+		return fmt.Errorf("METERING_REJECTION_ALERT_THRESHOLD and METERING_CIRCUIT_BREAKER_FAILURES must not be negative")
+	}
+
+	switch c.DuplicatePolicy {
+	case DuplicatePolicyWarn, DuplicatePolicyReject:
+		return nil
+	default:
+		return fmt.Errorf("invalid duplicate policy '%s'", c.DuplicatePolicy)
+	}
+}
+
+// HasResource returns true when the configuration identifies a resource to bill
+func (c Configuration) HasResource() bool {
+	return c.ResourceUri != ""
+}
+
+func (t OfferType) validate() error {
+	switch t {
+	case OfferTypeManagedApplication, OfferTypeSaaS:
+		return nil
+	default:
+		return fmt.Errorf("invalid offer type '%s'", t)
+	}
+}
+
+func (c Configuration) validateCaps() error {
+	switch c.CapPolicy {
+	case CapPolicyTruncate, CapPolicySkip, CapPolicyHold:
+	default:
+		return fmt.Errorf("invalid cap policy '%s'", c.CapPolicy)
+	}
+
+	for dimension, limit := range c.DimensionCaps {
+		if limit < 0 {
+			return fmt.Errorf("cap of dimension '%s' must not be negative", dimension)
+		}
+	}
+
+	for _, threshold := range c.CapAlertThresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("cap alert threshold %v must be positive", threshold)
+		}
+	}
+
+	return nil
+}
+
+// alerter returns the alerter of the configuration, or one that logs the alerts when it has none
+func (c Configuration) alerter(logger logging.Logger) Alerter {
+	if c.Alerter == nil {
+		return NewLogAlerter(logger)
+	}
+	return c.Alerter
+}
+
+// CapOf returns the monthly cap of the dimension, false when it has none
+func (c Configuration) CapOf(dimension string) (float64, bool) {
+	limit, ok := c.DimensionCaps[dimension]
+	return limit, ok
+}
+
+// NormalizeStartTime converts the start time of an event to UTC, truncated to the hour when configured.
+func (c Configuration) NormalizeStartTime(startAt time.Time) time.Time {
+	startAt = startAt.UTC()
+	if c.TruncateStartTime {
+		return startAt.Truncate(time.Hour)
+	}
+	return startAt
+}
+
+// PlanAt returns the plan the usage that started at the time is billed against
+func (c Configuration) PlanAt(startAt time.Time) string {
+	if c.Plans != nil {
+		if planID, ok := c.Plans.PlanID(c.ResourceUri, startAt); ok {
+			return planID
+		}
+	}
+	return c.PlanId
+}
+
+// SkipReason returns the reason why an event should not be sent, an event is skipped when its quantity
+// is not above the threshold of the dimension, or the default threshold if the dimension has none.
+func (c Configuration) SkipReason(dimension string, quantity float32) (string, bool) {
+	threshold, ok := c.DimensionSkipThresholds[dimension]
+	if !ok {
+		threshold = c.SkipThreshold
+	}
+
+	if quantity > threshold {
+		return "", false
+	}
+
+	return fmt.Sprintf("quantity %v is not above the threshold %v of dimension '%s'", quantity, threshold, dimension), true
 }
@@ -0,0 +1,41 @@
+package metering_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+func TestNormalizeStartTime(t *testing.T) {
+	lisbon := time.FixedZone("WEST", 3600)
+	startAt := time.Date(2024, 5, 1, 11, 42, 17, 500, lisbon)
+
+	tt := []struct {
+		name     string
+		truncate bool
+		expected time.Time
+	}{
+		{
+			name:     "converts to UTC",
+			truncate: false,
+			expected: time.Date(2024, 5, 1, 10, 42, 17, 500, time.UTC),
+		},
+		{
+			name:     "truncates to the hour",
+			truncate: true,
+			expected: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := metering.Configuration{TruncateStartTime: tc.truncate}
+
+			normalized := configuration.NormalizeStartTime(startAt)
+			if !normalized.Equal(tc.expected) || normalized.Location() != time.UTC {
+				t.Fatalf("should be %v, got %v", tc.expected, normalized)
+			}
+		})
+	}
+}
@@ -3,14 +3,20 @@ package metering
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/ydataai/go-core/pkg/common/config"
 	"github.com/ydataai/go-core/pkg/common/server"
 	coreMetering "github.com/ydataai/go-core/pkg/metering"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/openapi"
 )
 
 // RESTController defines rest controller
@@ -18,30 +24,64 @@ type RESTController struct {
 	logger           logging.Logger
 	configuration    config.RESTControllerConfiguration
 	markeplaceClient Client
+	guard            auth.Guard
 }
 
 // NewRESTController initializes rest controller
 func NewRESTController(
 	logger logging.Logger,
 	marketplaceClient Client,
+	guard auth.Guard,
 	configuration config.RESTControllerConfiguration,
 ) RESTController {
 	return RESTController{
 		logger:           logger,
 		configuration:    configuration,
 		markeplaceClient: marketplaceClient,
+		guard:            guard,
 	}
 }
 
 // Boot ...
 func (r RESTController) Boot(s server.Server) {
-	s.Router().POST("/metering/usageEvent", r.usageEvent())
-	s.Router().POST("/metering/batchUsageEvent", r.batchUsageEvent())
+	for _, prefix := range []string{"/metering", "/tenants/:tenantId/metering"} {
+		group := s.Router().Group(prefix, r.guard.Require(auth.ScopeMeteringWrite))
+		group.POST("/usageEvent", r.usageEvent())
+		group.POST("/batchUsageEvent", r.batchUsageEvent())
+	}
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r RESTController) Describe(spec *openapi.Spec) {
+	event := spec.Schema(coreMetering.UsageEvent{}, "dimensionId", "quantity", "startAt")
+	event.Value.Properties["dimensionId"] = openapi3.NewStringSchema().WithMinLength(1).NewRef()
+	event.Value.AdditionalProperties = openapi3.AdditionalProperties{Has: openapi3.BoolPtr(false)}
+
+	batch := spec.Schema(coreMetering.UsageEventBatch{}, "events")
+	batch.Value.Properties["events"] = openapi3.NewArraySchema().WithItems(event.Value).WithMinItems(1).NewRef()
+
+	for _, route := range tenantRoutes() {
+		spec.AddOperation(http.MethodPost, route.prefix+"/usageEvent", openapi.Operation{
+			ID:         "createUsageEvent" + route.suffix,
+			Summary:    "Sends an usage event to the marketplace metering API",
+			Parameters: route.parameters,
+			Request:    event,
+			Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(UsageEventResponse{}, "status")},
+		})
+
+		spec.AddOperation(http.MethodPost, route.prefix+"/batchUsageEvent", openapi.Operation{
+			ID:         "batchCreateUsageEvent" + route.suffix,
+			Summary:    "Sends a batch of usage events, the results keep the order of the events",
+			Parameters: route.parameters,
+			Request:    batch,
+			Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(UsageEventBatchResponse{}, "result")},
+		})
+	}
 }
 
 func (r RESTController) usageEvent() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		tCtx, cancel := context.WithTimeout(WithTenant(ctx, tenantID(ctx)), r.configuration.HTTPRequestTimeout)
 		defer cancel()
 
 		event := coreMetering.UsageEvent{}
@@ -50,16 +90,17 @@ func (r RESTController) usageEvent() gin.HandlerFunc {
 			return
 		}
 
-		r.logger.Infof("got event %+v", event)
+		logger := correlation.Logger(ctx, r.logger)
+		logger.Infof("got event of dimension '%s' = %v at %s", event.DimensionID, event.Quantity, event.StartAt.Format(TimeLayout))
 
 		response, err := r.markeplaceClient.CreateUsageEvent(tCtx, event)
 		if err != nil {
-			r.logger.Errorf("failed with error %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			logger.Errorf("failed with error %v", err)
+			ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
 			return
 		}
 
-		r.logger.Infof("got response %+v", response)
+		logger.Infof("got response with status %s", response.Status)
 
 		ctx.JSON(http.StatusOK, response)
 	}
@@ -67,7 +108,7 @@ func (r RESTController) usageEvent() gin.HandlerFunc {
 
 func (r RESTController) batchUsageEvent() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		tCtx, cancel := context.WithTimeout(WithTenant(ctx, tenantID(ctx)), r.configuration.HTTPRequestTimeout)
 		defer cancel()
 
 		event := coreMetering.UsageEventBatch{}
@@ -76,17 +117,62 @@ func (r RESTController) batchUsageEvent() gin.HandlerFunc {
 			return
 		}
 
-		r.logger.Infof("got event %+v", event)
+		logger := correlation.Logger(ctx, r.logger)
+		logger.Infof("got batch of %d events", len(event.Events))
 
 		response, err := r.markeplaceClient.BatchCreateUsageEvent(tCtx, event)
 		if err != nil {
-			r.logger.Errorf("failed with error %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			logger.Errorf("failed with error %v", err)
+			ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
 			return
 		}
 
-		r.logger.Infof("got response %+v", response)
+		logger.Infof("got response with %d results", len(response.Result))
 
 		ctx.JSON(http.StatusOK, response)
 	}
 }
+
+// tenantRoute is a prefix of the metering routes, with the parameters that identify the tenant
+type tenantRoute struct {
+	prefix     string
+	suffix     string
+	parameters openapi3.Parameters
+}
+
+// tenantRoutes returns the prefixes of the metering routes, where the tenant is in the header or in the path,
+// and the suffix of their operation ids
+func tenantRoutes() []tenantRoute {
+	tenantHeader := openapi3.Parameters{{Value: openapi3.NewHeaderParameter(TenantHeader).
+		WithDescription("Tenant of the registry, the default tenant when missing").
+		WithSchema(openapi3.NewStringSchema())}}
+	tenantPath := openapi3.Parameters{{Value: openapi3.NewPathParameter("tenantId").
+		WithDescription("Tenant of the registry").
+		WithSchema(openapi3.NewStringSchema())}}
+
+	return []tenantRoute{
+		{prefix: "/metering", parameters: tenantHeader},
+		{prefix: "/tenants/:tenantId/metering", suffix: "ForTenant", parameters: tenantPath},
+	}
+}
+
+// tenantID returns the tenant of the path, or of the header when the route has no tenant
+func tenantID(ctx *gin.Context) string {
+	if tenantID := ctx.Param("tenantId"); tenantID != "" {
+		return tenantID
+	}
+	return ctx.GetHeader(TenantHeader)
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownTenant):
+		return http.StatusNotFound
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
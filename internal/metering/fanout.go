@@ -0,0 +1,229 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+const sinkStatusFailed = "Failed"
+
+var errFanOutClosed = errors.New("metering fan-out is closed")
+
+// FanOutClient defines a metering client that also delivers the usage events to secondary sinks
+type FanOutClient interface {
+	Client
+	// Close stops accepting events and waits for the sinks to drain their queues until the context is done.
+	Close(ctx context.Context) error
+}
+
+type fanOutClient struct {
+	primary     Client
+	logger      logging.Logger
+	dispatchers []*sinkDispatcher
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewFanOutClient initializes a metering client that delivers every usage event to the primary client,
+// and asynchronously its result to each one of the sinks, which select the statuses they record. Each sink has its own queue and retries, so a failing sink
+// never blocks nor fails the primary client.
+func NewFanOutClient(
+	primary Client, sinks []Sink, config SinkConfiguration, logger logging.Logger,
+) FanOutClient {
+	dispatchers := make([]*sinkDispatcher, 0, len(sinks))
+	for _, sink := range sinks {
+		dispatcher := newSinkDispatcher(sink, config, logger)
+		go dispatcher.run()
+		dispatchers = append(dispatchers, dispatcher)
+	}
+
+	return &fanOutClient{
+		primary:     primary,
+		logger:      logger,
+		dispatchers: dispatchers,
+	}
+}
+
+// CreateUsageEvent sends the event to the primary client and forwards it to the sinks when it was sent
+func (c *fanOutClient) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (UsageEventResponse, error) {
+	response, err := c.primary.CreateUsageEvent(ctx, event)
+
+	switch {
+	case err != nil:
+		c.dispatch(ctx, []SinkEvent{newSinkEvent(ctx, event, UsageEventResponse{}, err)})
+	case response.sent():
+		c.dispatch(ctx, []SinkEvent{newSinkEvent(ctx, event, response, nil)})
+	}
+
+	return response, err
+}
+
+// BatchCreateUsageEvent sends the batch to the primary client and forwards the events that were sent to the sinks
+func (c *fanOutClient) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*UsageEventBatchResponse, error) {
+	response, err := c.primary.BatchCreateUsageEvent(ctx, batch)
+
+	accepted := []SinkEvent{}
+	for i, event := range batch.Events {
+		switch {
+		case err != nil:
+			accepted = append(accepted, newSinkEvent(ctx, event, UsageEventResponse{}, err))
+		case i < len(response.Result) && response.Result[i].sent():
+			accepted = append(accepted, newSinkEvent(ctx, event, response.Result[i], nil))
+		}
+	}
+
+	if len(accepted) > 0 {
+		c.dispatch(ctx, accepted)
+	}
+
+	return response, err
+}
+
+// Reload forwards the configuration to the primary client, when it is reloadable
+func (c *fanOutClient) Reload(config Configuration) {
+	if reloadable, ok := c.primary.(Reloadable); ok {
+		reloadable.Reload(config)
+	}
+}
+
+// Close stops accepting events and waits for the sinks to drain their queues until the context is done
+func (c *fanOutClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errFanOutClosed
+	}
+	c.closed = true
+	for _, dispatcher := range c.dispatchers {
+		close(dispatcher.queue)
+	}
+	c.mu.Unlock()
+
+	var err error
+	for _, dispatcher := range c.dispatchers {
+		select {
+		case <-dispatcher.done:
+		case <-ctx.Done():
+			dispatcher.cancel()
+			<-dispatcher.done
+			err = ctx.Err()
+		}
+
+		if closeErr := dispatcher.sink.Close(); closeErr != nil {
+			c.logger.Errorf("failed to close sink '%s'. Err: %v", dispatcher.sink.Name(), closeErr)
+		}
+	}
+
+	return err
+}
+
+func (c *fanOutClient) dispatch(ctx context.Context, events []SinkEvent) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		correlation.Logger(ctx, c.logger).Errorf("%v, dropping %d events", errFanOutClosed, len(events))
+		return
+	}
+
+	for _, dispatcher := range c.dispatchers {
+		select {
+		case dispatcher.queue <- events:
+		default:
+			correlation.Logger(ctx, c.logger).Errorf("queue of sink '%s' is full, dropping %d events", dispatcher.sink.Name(), len(events))
+		}
+	}
+}
+
+func newSinkEvent(
+	ctx context.Context, event coreMetering.UsageEvent, response UsageEventResponse, err error,
+) SinkEvent {
+	sinkEvent := SinkEvent{
+		UsageEvent:   event,
+		TenantID:     TenantFromContext(ctx),
+		PlanID:       response.PlanID,
+		UsageEventID: response.UsageEventID,
+		Status:       response.Status,
+		RecordedAt:   time.Now().UTC(),
+	}
+
+	if err != nil {
+		sinkEvent.Status = sinkStatusFailed
+	}
+
+	return sinkEvent
+}
+
+type sinkDispatcher struct {
+	sink   Sink
+	config SinkConfiguration
+	logger logging.Logger
+
+	queue  chan []SinkEvent
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newSinkDispatcher(sink Sink, config SinkConfiguration, logger logging.Logger) *sinkDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &sinkDispatcher{
+		sink:   sink,
+		config: config,
+		logger: logger,
+		queue:  make(chan []SinkEvent, config.QueueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (d *sinkDispatcher) run() {
+	defer close(d.done)
+	defer d.cancel()
+
+	for events := range d.queue {
+		d.deliver(events)
+	}
+}
+
+// deliver sends the events to the sink, retrying with exponential backoff until the max attempts is reached
+func (d *sinkDispatcher) deliver(events []SinkEvent) {
+	backoff := d.config.RetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := d.sink.Send(d.ctx, events)
+		if err == nil {
+			return
+		}
+
+		if attempt >= d.config.MaxAttempts || d.ctx.Err() != nil {
+			d.logger.Errorf("sink '%s' dropped %d events after %d attempts. Err: %v",
+				d.sink.Name(), len(events), attempt, err)
+			return
+		}
+
+		d.logger.Warnf("sink '%s' failed attempt %d, retrying in %v. Err: %v", d.sink.Name(), attempt, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-d.ctx.Done():
+		}
+
+		backoff = min(backoff*2, d.config.MaxRetryBackoff)
+	}
+}
@@ -0,0 +1,183 @@
+package metering_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+type fakeSink struct {
+	mu     sync.Mutex
+	err    error
+	calls  int
+	events []metering.SinkEvent
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Send(_ context.Context, events []metering.SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func TestFanOutClient(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	sinkConfiguration := metering.SinkConfiguration{
+		QueueSize:       10,
+		MaxAttempts:     3,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+	}
+
+	startAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("failing sink does not affect the primary client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: startAt}
+		expected := metering.UsageEventResponse{
+			UsageEventResponse: coreMetering.UsageEventResponse{UsageEventID: "id", DimensionID: "gpu", Status: "Accepted"},
+		}
+
+		primary := mock.NewMockMeteringClient(ctrl)
+		primary.EXPECT().CreateUsageEvent(gomock.Any(), event).Return(expected, nil)
+
+		failing := &fakeSink{err: errors.New("sink unavailable")}
+		healthy := &fakeSink{}
+
+		client := metering.NewFanOutClient(
+			primary, []metering.Sink{failing, healthy}, sinkConfiguration, logger)
+
+		response, err := client.CreateUsageEvent(ctx, event)
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		if diff := cmp.Diff(expected, response); diff != "" {
+			t.Fatal(diff)
+		}
+
+		if err := client.Close(ctx); err != nil {
+			t.Fatalf("should drain without error, got %v", err)
+		}
+
+		if failing.calls != sinkConfiguration.MaxAttempts {
+			t.Fatalf("should retry %d times, got %d", sinkConfiguration.MaxAttempts, failing.calls)
+		}
+
+		if len(healthy.events) != 1 || healthy.events[0].Status != "Accepted" {
+			t.Fatalf("healthy sink should receive the accepted event, got %+v", healthy.events)
+		}
+	})
+
+	t.Run("batch forwards only the events that weren't skipped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		batch := coreMetering.UsageEventBatch{Events: []coreMetering.UsageEvent{
+			{DimensionID: "gpu", Quantity: 2, StartAt: startAt},
+			{DimensionID: "cpu", Quantity: 0, StartAt: startAt},
+			{DimensionID: "ram", Quantity: 4, StartAt: startAt},
+		}}
+
+		primary := mock.NewMockMeteringClient(ctrl)
+		primary.EXPECT().BatchCreateUsageEvent(gomock.Any(), batch).Return(&metering.UsageEventBatchResponse{
+			Result: []metering.UsageEventResponse{
+				{UsageEventResponse: coreMetering.UsageEventResponse{UsageEventID: "1", DimensionID: "gpu", Status: "Accepted"}},
+				{UsageEventResponse: coreMetering.UsageEventResponse{DimensionID: "cpu", Status: metering.StatusSkipped}},
+				{UsageEventResponse: coreMetering.UsageEventResponse{UsageEventID: "2", DimensionID: "ram", Status: "Duplicate"}},
+			},
+		}, nil)
+
+		sink := &fakeSink{}
+		client := metering.NewFanOutClient(primary, []metering.Sink{sink}, sinkConfiguration, logger)
+
+		if _, err := client.BatchCreateUsageEvent(ctx, batch); err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		if err := client.Close(ctx); err != nil {
+			t.Fatalf("should drain without error, got %v", err)
+		}
+
+		statuses := map[string]string{}
+		for _, event := range sink.events {
+			statuses[event.DimensionID] = event.Status
+		}
+
+		if diff := cmp.Diff(map[string]string{"gpu": "Accepted", "ram": "Duplicate"}, statuses); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
+
+func TestSinkStatuses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.ndjson")
+
+	sinks, err := metering.NewSinks(metering.SinkConfiguration{FilePath: path, Statuses: []string{"Accepted"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []metering.SinkEvent{
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1}, Status: "Accepted"},
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 2}, Status: "Failed"},
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 3}, Status: "Duplicate"},
+	}
+	if err := sinks[0].Send(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	if err := sinks[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := metering.SinkEvent{}
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatalf("expected a single event, got %s", data)
+	}
+	if recorded.Quantity != 1 {
+		t.Fatalf("expected the accepted event, got %+v", recorded)
+	}
+}
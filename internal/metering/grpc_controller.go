@@ -0,0 +1,148 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	adapterv1 "github.com/ydataai/azure-adapter/api/adapter/v1"
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+)
+
+// GRPCController defines the gRPC controller of the metering service
+type GRPCController struct {
+	adapterv1.UnimplementedMeteringServiceServer
+
+	logger           logging.Logger
+	configuration    rpc.ServerConfiguration
+	markeplaceClient Client
+}
+
+// NewGRPCController initializes the gRPC controller
+func NewGRPCController(
+	logger logging.Logger,
+	marketplaceClient Client,
+	configuration rpc.ServerConfiguration,
+) GRPCController {
+	return GRPCController{
+		logger:           logger,
+		configuration:    configuration,
+		markeplaceClient: marketplaceClient,
+	}
+}
+
+// Boot registers the metering service
+func (g GRPCController) Boot(s rpc.Server) {
+	adapterv1.RegisterMeteringServiceServer(s.Registrar(), g)
+}
+
+// Scopes returns the scopes each method requires, the same as the REST routes
+func (g GRPCController) Scopes() map[string][]string {
+	return map[string][]string{
+		adapterv1.MeteringService_CreateUsageEvent_FullMethodName:      {auth.ScopeMeteringWrite},
+		adapterv1.MeteringService_BatchCreateUsageEvent_FullMethodName: {auth.ScopeMeteringWrite},
+	}
+}
+
+// CreateUsageEvent sends a single usage event
+func (g GRPCController) CreateUsageEvent(
+	ctx context.Context, req *adapterv1.CreateUsageEventRequest,
+) (*adapterv1.CreateUsageEventResponse, error) {
+	tCtx, cancel := context.WithTimeout(WithTenant(ctx, tenantFromMetadata(ctx)), g.configuration.RequestTimeout)
+	defer cancel()
+
+	event, err := usageEventFromProto(req.GetEvent())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.markeplaceClient.CreateUsageEvent(tCtx, event)
+	if err != nil {
+		correlation.Logger(ctx, g.logger).Errorf("failed with error %v", err)
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+
+	return &adapterv1.CreateUsageEventResponse{Result: usageEventResultToProto(response)}, nil
+}
+
+// BatchCreateUsageEvent sends a batch of usage events
+func (g GRPCController) BatchCreateUsageEvent(
+	ctx context.Context, req *adapterv1.BatchCreateUsageEventRequest,
+) (*adapterv1.BatchCreateUsageEventResponse, error) {
+	tCtx, cancel := context.WithTimeout(WithTenant(ctx, tenantFromMetadata(ctx)), g.configuration.RequestTimeout)
+	defer cancel()
+
+	batch := coreMetering.UsageEventBatch{Events: make([]coreMetering.UsageEvent, 0, len(req.GetEvents()))}
+	for _, protoEvent := range req.GetEvents() {
+		event, err := usageEventFromProto(protoEvent)
+		if err != nil {
+			return nil, err
+		}
+		batch.Events = append(batch.Events, event)
+	}
+
+	response, err := g.markeplaceClient.BatchCreateUsageEvent(tCtx, batch)
+	if err != nil {
+		correlation.Logger(ctx, g.logger).Errorf("failed with error %v", err)
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+
+	results := make([]*adapterv1.UsageEventResult, 0, len(response.Result))
+	for _, result := range response.Result {
+		results = append(results, usageEventResultToProto(result))
+	}
+
+	return &adapterv1.BatchCreateUsageEventResponse{Result: results}, nil
+}
+
+// tenantFromMetadata returns the tenant of the request metadata, which uses the same key as the REST header
+func tenantFromMetadata(ctx context.Context) string {
+	values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(TenantHeader))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, ErrUnknownTenant):
+		return codes.NotFound
+	case errors.Is(err, ErrRateLimited):
+		return codes.ResourceExhausted
+	case errors.Is(err, ErrCircuitOpen):
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func usageEventFromProto(event *adapterv1.UsageEvent) (coreMetering.UsageEvent, error) {
+	if event == nil || event.GetDimensionId() == "" || event.GetStartAt() == nil {
+		return coreMetering.UsageEvent{}, status.Error(codes.InvalidArgument, "dimension_id and start_at are required")
+	}
+
+	return coreMetering.UsageEvent{
+		DimensionID: event.GetDimensionId(),
+		Quantity:    event.GetQuantity(),
+		StartAt:     event.GetStartAt().AsTime(),
+	}, nil
+}
+
+func usageEventResultToProto(response UsageEventResponse) *adapterv1.UsageEventResult {
+	return &adapterv1.UsageEventResult{
+		UsageEventId: response.UsageEventID,
+		DimensionId:  response.DimensionID,
+		Status:       response.Status,
+		Reason:       response.Reason,
+	}
+}
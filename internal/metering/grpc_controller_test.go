@@ -0,0 +1,140 @@
+package metering_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	adapterv1 "github.com/ydataai/azure-adapter/api/adapter/v1"
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestGRPCCreateUsageEvent(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	startAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 2, StartAt: startAt}
+
+	meteringClient := mock.NewMockMeteringClient(ctrl)
+	meteringClient.EXPECT().CreateUsageEvent(gomock.Any(), event).
+		DoAndReturn(func(ctx context.Context, event coreMetering.UsageEvent) (metering.UsageEventResponse, error) {
+			if tenantID := metering.TenantFromContext(ctx); tenantID != "contoso" {
+				t.Errorf("expected the tenant of the metadata, got '%s'", tenantID)
+			}
+			return accepted(event), nil
+		})
+
+	guard := auth.NewGuard(logger, []auth.Authenticator{
+		auth.NewHMACAuthenticator(map[string]string{"partner": "secret"},
+			map[string]string{"partner": auth.ScopeMeteringWrite}, time.Minute),
+		auth.NewBearerAuthenticator(map[string]string{"writer": auth.ScopeMeteringWrite, "reader": auth.ScopeQuotaRead}),
+	})
+	controller := metering.NewGRPCController(logger, meteringClient, rpc.ServerConfiguration{RequestTimeout: time.Second})
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(guard.UnaryServerInterceptor(controller.Scopes())))
+	controller.Boot(registrar{grpcServer})
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+	defer conn.Close()
+
+	client := adapterv1.NewMeteringServiceClient(conn)
+	request := &adapterv1.CreateUsageEventRequest{Event: &adapterv1.UsageEvent{
+		DimensionId: "gpu", Quantity: 2, StartAt: timestamppb.New(startAt),
+	}}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := auth.Sign([]byte("secret"), http.MethodPost, adapterv1.MeteringService_CreateUsageEvent_FullMethodName,
+		timestamp, nil)
+
+	tt := []struct {
+		name  string
+		token string
+		hmac  bool
+		code  codes.Code
+	}{
+		{name: "without credentials", code: codes.Unauthenticated},
+		{name: "hmac signature", hmac: true, code: codes.Unauthenticated},
+		{name: "invalid token", token: "unknown", code: codes.Unauthenticated},
+		{name: "missing scopes", token: "reader", code: codes.PermissionDenied},
+		{name: "granted", token: "writer", code: codes.OK},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), metering.TenantHeader, "contoso")
+			if tc.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tc.token)
+			}
+			if tc.hmac {
+				ctx = metadata.AppendToOutgoingContext(ctx, auth.HMACKeyIDHeader, "partner",
+					auth.HMACTimestampHeader, timestamp, auth.HMACSignatureHeader, signature)
+			}
+
+			response, err := client.CreateUsageEvent(ctx, request)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("expected code %v, got %v", tc.code, err)
+			}
+			if tc.code == codes.OK && response.GetResult().GetStatus() != metering.StatusAccepted {
+				t.Fatalf("expected the event to be accepted, got %+v", response.GetResult())
+			}
+		})
+	}
+}
+
+// registrar exposes a plain grpc.Server as an rpc.Server for the controllers to register on
+type registrar struct {
+	server *grpc.Server
+}
+
+func (r registrar) Registrar() grpc.ServiceRegistrar {
+	return r.server
+}
+
+func (r registrar) Configuration() rpc.ServerConfiguration {
+	return rpc.ServerConfiguration{}
+}
+
+func (r registrar) Run(context.Context) error {
+	return nil
+}
+
+func (r registrar) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// Installations defines the lifecycle of the installations, whose usage isn't billed once they are deleted or failed
+type Installations interface {
+	// SkipReason returns the reason why the usage of the resource should not be sent
+	SkipReason(resourceURI string) (string, bool)
+}
+
+type installationClient struct {
+	client        Client
+	resourceURI   string
+	installations Installations
+	logger        logging.Logger
+}
+
+// NewInstallationClient initializes a client that skips the usage events of the resource
+// while the installations report it shouldn't be billed
+func NewInstallationClient(
+	client Client, resourceURI string, installations Installations, logger logging.Logger,
+) Client {
+	return installationClient{
+		client:        client,
+		resourceURI:   resourceURI,
+		installations: installations,
+		logger:        logger,
+	}
+}
+
+// CreateUsageEvent sends the event with the client, unless the installation isn't billable
+func (c installationClient) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (UsageEventResponse, error) {
+	if reason, skip := c.installations.SkipReason(c.resourceURI); skip {
+		return c.skippedResponse(ctx, event, reason), nil
+	}
+
+	return c.client.CreateUsageEvent(ctx, event)
+}
+
+// BatchCreateUsageEvent sends the batch with the client, unless the installation isn't billable
+func (c installationClient) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*UsageEventBatchResponse, error) {
+	if reason, skip := c.installations.SkipReason(c.resourceURI); skip {
+		results := make([]UsageEventResponse, len(batch.Events))
+		for i, event := range batch.Events {
+			results[i] = c.skippedResponse(ctx, event, reason)
+		}
+		return &UsageEventBatchResponse{Result: results}, nil
+	}
+
+	return c.client.BatchCreateUsageEvent(ctx, batch)
+}
+
+// Reload forwards the configuration to the client, when it is reloadable
+func (c installationClient) Reload(config Configuration) {
+	if reloadable, ok := c.client.(Reloadable); ok {
+		reloadable.Reload(config)
+	}
+}
+
+func (c installationClient) skippedResponse(
+	ctx context.Context, event coreMetering.UsageEvent, reason string,
+) UsageEventResponse {
+	correlation.Logger(ctx, c.logger).Warnf("metric '%s' skipped = %v, %s", event.DimensionID, event.Quantity, reason)
+
+	return UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{
+			DimensionID: event.DimensionID,
+			Status:      StatusSkipped,
+		},
+		Reason: reason,
+	}
+}
@@ -0,0 +1,144 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Ledger defines a sink that keeps the usage events sent to the marketplace, so the adapter can report on them
+type Ledger interface {
+	Sink
+	// Entries returns the recorded events that started in [from, to), in the order they were recorded
+	Entries(from, to time.Time) []SinkEvent
+}
+
+// Accounts defines the ledger and the plan of each tenant, which the reports of the usage are based on
+type Accounts interface {
+	// Ledger returns the ledger of the tenant
+	Ledger(tenantID string) (Ledger, error)
+	// PlanAt returns the plan the usage of the tenant that started at the time is billed against
+	PlanAt(tenantID string, at time.Time) (string, error)
+}
+
+type ledger struct {
+	retention time.Duration
+	file      Sink
+
+	mu      sync.RWMutex
+	entries []SinkEvent
+}
+
+// NewLedger initializes a ledger that keeps the events started within the retention.
+// When the path isn't empty the events are also appended to the file, and the ones already there are loaded.
+func NewLedger(path string, retention time.Duration) (Ledger, error) {
+	l := &ledger{retention: retention, entries: []SinkEvent{}}
+
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.load(path); err != nil {
+		return nil, fmt.Errorf("could not load ledger %s. Err: %v", path, err)
+	}
+
+	file, err := NewFileSink(path)
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+
+	return l, nil
+}
+
+func (l *ledger) Name() string {
+	return "ledger"
+}
+
+// Send records the events, persisting them first when the ledger has a file
+func (l *ledger) Send(ctx context.Context, events []SinkEvent) error {
+	if l.file != nil {
+		if err := l.file.Send(ctx, events); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, events...)
+	l.prune(time.Now())
+
+	return nil
+}
+
+// Entries returns the recorded events that started in [from, to), in the order they were recorded
+func (l *ledger) Entries(from, to time.Time) []SinkEvent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := []SinkEvent{}
+	for _, entry := range l.entries {
+		if !entry.StartAt.Before(from) && entry.StartAt.Before(to) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (l *ledger) Close() error {
+	if l.file != nil {
+		return l.file.Close()
+	}
+	return nil
+}
+
+func (l *ledger) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		entry := SinkEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		l.entries = append(l.entries, entry)
+	}
+	l.prune(time.Now())
+
+	return scanner.Err()
+}
+
+// prune drops the events that started before the retention, the file keeps them
+func (l *ledger) prune(now time.Time) {
+	if l.retention <= 0 {
+		return
+	}
+
+	oldest := now.Add(-l.retention)
+	kept := l.entries[:0]
+	for _, entry := range l.entries {
+		if !entry.StartAt.Before(oldest) {
+			kept = append(kept, entry)
+		}
+	}
+	l.entries = kept
+}
@@ -0,0 +1,53 @@
+package metering_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.ndjson")
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	events := []metering.SinkEvent{
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 1, StartAt: now.Add(-48 * time.Hour)}},
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 2, StartAt: now.Add(-2 * time.Hour)}},
+		{UsageEvent: coreMetering.UsageEvent{DimensionID: "cpu", Quantity: 3, StartAt: now.Add(-time.Hour)}},
+	}
+
+	ledger, err := metering.NewLedger(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	if err := ledger.Send(context.Background(), events); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	if err := ledger.Close(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	reloaded, err := metering.NewLedger(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+	defer reloaded.Close()
+
+	quantities := []float32{}
+	for _, entry := range reloaded.Entries(now.Add(-72*time.Hour), now.Add(-time.Hour)) {
+		quantities = append(quantities, entry.Quantity)
+	}
+
+	// the first event is out of the retention and the last one out of the range
+	if diff := cmp.Diff([]float32{2}, quantities); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -2,18 +2,66 @@
 package metering
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
+
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+// Usage event statuses, besides the ones returned by azure the adapter reports the events it didn't send
+const (
+	StatusAccepted = "Accepted"
+	// StatusDuplicate is returned by azure when the usage of the dimension and hour was already accepted
+	StatusDuplicate = "Duplicate"
+	// StatusExpired is returned by azure when the usage event started more than 24 hours ago
+	StatusExpired  = "Expired"
+	StatusSkipped  = "Skipped"
+	StatusUnknown  = "Unknown"
+	StatusRejected = "Rejected"
+	// StatusHeld is reported for the events above the cap of their dimension, which are sent once the cap is raised
+	StatusHeld = "Held"
 )
 
+// UsageEventResponse represents the result of an usage event, with the reason when the adapter didn't send it
+type UsageEventResponse struct {
+	coreMetering.UsageEventResponse
+	// PlanID is the plan azure billed the event against
+	PlanID string `json:"planId,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// sent returns false when the adapter didn't send the event to azure
+func (r UsageEventResponse) sent() bool {
+	return r.Status != StatusSkipped && r.Status != StatusRejected && r.Status != StatusHeld
+}
+
+// UsageEventBatchResponse represents the results of a batch, aligned with the order of the requested events
+type UsageEventBatchResponse struct {
+	Result []UsageEventResponse `json:"result"`
+}
+
 // UsageEventReq a type to represent the usage metering event request
 type usageEvent struct {
 	Dimension          string    `json:"dimension"`
 	Quantity           float32   `json:"quantity"`
 	EffectiveStartTime time.Time `json:"effectiveStartTime"`
 
-	ResourceURI string `json:"resourceUri"` // unique identifier of the resource against which usage is emitted.
-	PlanID      string `json:"planId"`      // id of the plan purchased for the offer
+	ResourceURI string `json:"resourceUri,omitempty"` // unique identifier of the managed application against which usage is emitted.
+	ResourceID  string `json:"resourceId,omitempty"`  // unique identifier of the SaaS subscription against which usage is emitted.
+	PlanID      string `json:"planId"`                // id of the plan purchased for the offer
+}
+
+// MarshalJSON serializes the event with the effective start time in TimeLayout
+func (e usageEvent) MarshalJSON() ([]byte, error) {
+	type event usageEvent
+	return json.Marshal(struct {
+		event
+		EffectiveStartTime string `json:"effectiveStartTime"`
+	}{
+		event:              event(e),
+		EffectiveStartTime: e.EffectiveStartTime.UTC().Format(TimeLayout),
+	})
 }
 
 // UsageEventRes a type to represent the usage metering event response
@@ -49,3 +97,15 @@ type usageEventBatchResponse struct {
 	Count          int                  `json:"count"`  // number of records in the response
 	Result         []usageEventResponse `json:"result"` // result
 }
+
+func (r usageEventResponse) toUsageEventResponse() UsageEventResponse {
+	return UsageEventResponse{
+		UsageEventResponse: coreMetering.UsageEventResponse{
+			UsageEventID: r.UsageEventId,
+			DimensionID:  r.Dimension,
+			Status:       r.Status,
+		},
+		PlanID: r.PlanId,
+		Reason: r.Error.Message,
+	}
+}
@@ -0,0 +1,141 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+type monitorClient struct {
+	client      Client
+	resourceURI string
+	threshold   int
+	alerter     Alerter
+
+	mu sync.Mutex
+	// rejections counts the consecutive rejections of each dimension
+	rejections map[string]int
+}
+
+// NewMonitorClient initializes a client that alerts of the failures to send the usage events, of the events
+// that expired and of the dimensions rejected the number of consecutive times of the configuration
+func NewMonitorClient(client Client, config Configuration, logger logging.Logger) Client {
+	return &monitorClient{
+		client:      client,
+		resourceURI: config.ResourceUri,
+		threshold:   config.RejectionAlertThreshold,
+		alerter:     config.alerter(logger),
+		rejections:  map[string]int{},
+	}
+}
+
+// CreateUsageEvent sends the event with the client and alerts of its result
+func (c *monitorClient) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (UsageEventResponse, error) {
+	response, err := c.client.CreateUsageEvent(ctx, event)
+	if err != nil {
+		c.failed(ctx, 1, err)
+		return response, err
+	}
+
+	c.observe(ctx, event, response)
+	return response, nil
+}
+
+// BatchCreateUsageEvent sends the batch with the client and alerts of the results of its events
+func (c *monitorClient) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*UsageEventBatchResponse, error) {
+	response, err := c.client.BatchCreateUsageEvent(ctx, batch)
+	if err != nil {
+		c.failed(ctx, len(batch.Events), err)
+		return response, err
+	}
+
+	for i, event := range batch.Events {
+		if i < len(response.Result) {
+			c.observe(ctx, event, response.Result[i])
+		}
+	}
+	return response, nil
+}
+
+// Reload forwards the configuration to the client, when it is reloadable
+func (c *monitorClient) Reload(config Configuration) {
+	c.mu.Lock()
+	c.threshold = config.RejectionAlertThreshold
+	c.mu.Unlock()
+
+	if reloadable, ok := c.client.(Reloadable); ok {
+		reloadable.Reload(config)
+	}
+}
+
+// failed alerts of the events that couldn't be sent, the circuit breaker alerts once for the events it refuses
+func (c *monitorClient) failed(ctx context.Context, events int, err error) {
+	if errors.Is(err, ErrCircuitOpen) {
+		return
+	}
+
+	c.alert(ctx, Alert{
+		Kind:    AlertSubmissionFailed,
+		Message: fmt.Sprintf("%d usage events couldn't be sent. Err: %v", events, err),
+		Count:   events,
+	})
+}
+
+func (c *monitorClient) observe(ctx context.Context, event coreMetering.UsageEvent, response UsageEventResponse) {
+	if response.Status == StatusExpired {
+		c.alert(ctx, Alert{
+			Kind:        AlertEventExpired,
+			DimensionID: event.DimensionID,
+			Status:      response.Status,
+			Message: fmt.Sprintf("usage event of dimension '%s' started at %s expired, quantity %v wasn't billed",
+				event.DimensionID, event.StartAt.UTC().Format(time.RFC3339), event.Quantity),
+		})
+	}
+
+	if count, ok := c.count(event.DimensionID, response); ok {
+		c.alert(ctx, Alert{
+			Kind:        AlertRepeatedRejections,
+			DimensionID: event.DimensionID,
+			Status:      response.Status,
+			Message: fmt.Sprintf("usage events of dimension '%s' were rejected %d consecutive times, last with %s: %s",
+				event.DimensionID, count, response.Status, response.Reason),
+			Count: count,
+		})
+	}
+}
+
+// count updates the consecutive rejections of the dimension, and returns true when they reach the threshold.
+// The events azure accepted, or that the adapter didn't send on purpose, aren't rejections.
+func (c *monitorClient) count(dimensionID string, response UsageEventResponse) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case response.Status == StatusAccepted || response.Status == StatusDuplicate:
+		delete(c.rejections, dimensionID)
+		return 0, false
+	case !response.sent():
+		return 0, false
+	}
+
+	c.rejections[dimensionID]++
+	count := c.rejections[dimensionID]
+	return count, c.threshold > 0 && count == c.threshold
+}
+
+func (c *monitorClient) alert(ctx context.Context, alert Alert) {
+	alert.TenantID = TenantFromContext(ctx)
+	alert.ResourceURI = c.resourceURI
+	alert.Time = time.Now().UTC()
+	c.alerter.Alert(ctx, alert)
+}
@@ -0,0 +1,273 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// Headers of the signed alert payloads, the signature is the HMAC-SHA256 of the timestamp, a dot and the body
+const (
+	AlertSignatureHeader = "X-Alert-Signature"
+	AlertTimestampHeader = "X-Alert-Timestamp"
+)
+
+// AlertFormat defines the payload of an alert webhook
+type AlertFormat string
+
+// Supported alert formats
+const (
+	// AlertFormatGeneric posts the alert as JSON
+	AlertFormatGeneric AlertFormat = "generic"
+	// AlertFormatSlack posts a message of a Slack incoming webhook
+	AlertFormatSlack AlertFormat = "slack"
+	// AlertFormatTeams posts a message card of a Microsoft Teams incoming webhook
+	AlertFormatTeams AlertFormat = "teams"
+)
+
+var errNotifierClosed = errors.New("alert notifier is closed")
+
+// AlertNotifier defines an alerter that delivers the alerts to the webhooks in the background
+type AlertNotifier interface {
+	Alerter
+	// Close stops accepting alerts and waits for the queued ones to be delivered until the context is done.
+	Close(ctx context.Context) error
+}
+
+type alertWebhook struct {
+	format AlertFormat
+	url    string
+}
+
+type alertNotifier struct {
+	config   AlertConfiguration
+	webhooks []alertWebhook
+	logger   logging.Logger
+	pl       coreHTTP.Pipeline
+
+	queue  chan Alert
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	// delivered is the time each condition was last delivered, to deduplicate the alerts
+	delivered map[string]time.Time
+}
+
+// NewAlertNotifier initializes an alerter that logs the alerts and posts them to each webhook of the
+// configuration. The alerts are queued so they never block the usage events, and dropped when the queue is full.
+func NewAlertNotifier(config AlertConfiguration, logger logging.Logger) AlertNotifier {
+	webhooks := []alertWebhook{}
+	for _, webhook := range []alertWebhook{
+		{format: AlertFormatGeneric, url: config.WebhookURL},
+		{format: AlertFormatSlack, url: config.SlackWebhookURL},
+		{format: AlertFormatTeams, url: config.TeamsWebhookURL},
+	} {
+		if webhook.url != "" {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &alertNotifier{
+		config:    config,
+		webhooks:  webhooks,
+		logger:    logger,
+		pl:        coreHTTP.NewPipeline(),
+		queue:     make(chan Alert, config.QueueSize),
+		done:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+		delivered: map[string]time.Time{},
+	}
+	go n.run()
+
+	return n
+}
+
+// Alert logs the alert and queues it for the webhooks, unless the same condition was delivered within the window.
+// The condition is delivered once the alert is queued, the alerts that are dropped are raised again.
+func (n *alertNotifier) Alert(ctx context.Context, alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now().UTC()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := alert.dedupKey()
+	if last, ok := n.delivered[key]; ok && alert.Time.Sub(last) < n.config.DedupWindow {
+		return
+	}
+
+	logger := correlation.Logger(ctx, n.logger)
+	logger.Warnf("alert %s of tenant '%s': %s", alert.Kind, alert.TenantID, alert.Message)
+
+	if len(n.webhooks) > 0 {
+		if n.closed {
+			logger.Errorf("%v, dropping alert %s", errNotifierClosed, alert.Kind)
+			return
+		}
+
+		select {
+		case n.queue <- alert:
+		default:
+			logger.Errorf("alert queue is full, dropping alert %s", alert.Kind)
+			return
+		}
+	}
+
+	n.delivered[key] = alert.Time
+	for key, last := range n.delivered {
+		if alert.Time.Sub(last) >= n.config.DedupWindow {
+			delete(n.delivered, key)
+		}
+	}
+}
+
+// Close stops accepting alerts and waits for the queued ones to be delivered until the context is done
+func (n *alertNotifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return errNotifierClosed
+	}
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-n.done
+		return ctx.Err()
+	}
+}
+
+func (n *alertNotifier) run() {
+	defer close(n.done)
+	defer n.cancel()
+
+	for alert := range n.queue {
+		for _, webhook := range n.webhooks {
+			if err := n.post(webhook, alert); err != nil {
+				n.logger.Errorf("failed to deliver alert %s to the %s webhook. Err: %v", alert.Kind, webhook.format, err)
+			}
+		}
+	}
+}
+
+// post sends the payload of the alert in the format of the webhook, any status code other than 2xx is a failure
+func (n *alertNotifier) post(webhook alertWebhook, alert Alert) error {
+	body, err := json.Marshal(webhook.format.payload(alert))
+	if err != nil {
+		return err
+	}
+
+	tCtx, cancel := context.WithTimeout(n.ctx, n.config.WebhookTimeout)
+	defer cancel()
+
+	req, err := coreHTTP.NewRequest(tCtx, http.MethodPost, webhook.url)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range n.config.WebhookHeaders {
+		req.Header.Set(key, value)
+	}
+
+	if n.config.SigningSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(AlertTimestampHeader, timestamp)
+		req.Header.Set(AlertSignatureHeader, AlertSignature(n.config.SigningSecret, timestamp, body))
+	}
+
+	if err := req.SetBody(readSeekNopCloser{bytes.NewReader(body)}, coreHTTP.ContentTypeAppJSON); err != nil {
+		return err
+	}
+
+	resp, err := n.pl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return invalidStatusCodeError(resp.Response)
+	}
+
+	return nil
+}
+
+// AlertSignature returns the signature of an alert payload, which the receivers compute to verify it
+func AlertSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// payload returns the body of the alert in the format
+func (f AlertFormat) payload(alert Alert) any {
+	title := fmt.Sprintf("Metering alert %s", alert.Kind)
+	if alert.TenantID != "" {
+		title += fmt.Sprintf(" of tenant '%s'", alert.TenantID)
+	}
+
+	switch f {
+	case AlertFormatSlack:
+		return map[string]any{"text": fmt.Sprintf("*%s*\n%s", title, alert.Message)}
+	case AlertFormatTeams:
+		facts := []map[string]string{}
+		for _, fact := range [][2]string{
+			{"Resource", alert.ResourceURI},
+			{"Dimension", alert.DimensionID},
+			{"Status", alert.Status},
+			{"Time", alert.Time.UTC().Format(time.RFC3339)},
+		} {
+			if fact[1] != "" {
+				facts = append(facts, map[string]string{"name": fact[0], "value": fact[1]})
+			}
+		}
+
+		return map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    title,
+			"themeColor": "D70000",
+			"title":      title,
+			"text":       alert.Message,
+			"sections":   []map[string]any{{"facts": facts}},
+		}
+	default:
+		return alert
+	}
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,168 @@
+package metering_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+func TestAlertNotifier(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	mu := sync.Mutex{}
+	payloads := map[string][]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		expected := metering.AlertSignature("secret", r.Header.Get(metering.AlertTimestampHeader), body)
+		if r.Header.Get(metering.AlertSignatureHeader) != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		payload := map[string]any{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		payloads[r.URL.Path] = append(payloads[r.URL.Path], payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	notifier := metering.NewAlertNotifier(metering.AlertConfiguration{
+		WebhookURL:      server.URL + "/generic",
+		SlackWebhookURL: server.URL + "/slack",
+		TeamsWebhookURL: server.URL + "/teams",
+		WebhookTimeout:  time.Second,
+		SigningSecret:   "secret",
+		DedupWindow:     time.Hour,
+		QueueSize:       10,
+	}, logger)
+
+	ctx := context.Background()
+	failure := metering.Alert{Kind: metering.AlertSubmissionFailed, TenantID: "contoso", Message: "azure is down"}
+	notifier.Alert(ctx, failure)
+	notifier.Alert(ctx, failure)
+	notifier.Alert(ctx, metering.Alert{Kind: metering.AlertEventExpired, TenantID: "contoso", DimensionID: "gpu",
+		Status: metering.StatusExpired, Message: "event expired"})
+
+	if err := notifier.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("deduplicates the alerts of each webhook", func(t *testing.T) {
+		for _, path := range []string{"/generic", "/slack", "/teams"} {
+			if len(payloads[path]) != 2 {
+				t.Fatalf("expected 2 alerts on %s, got %v", path, payloads[path])
+			}
+		}
+	})
+
+	t.Run("posts the payload of each format", func(t *testing.T) {
+		if kind := payloads["/generic"][0]["kind"]; kind != string(metering.AlertSubmissionFailed) {
+			t.Fatalf("expected the alert as JSON, got %v", payloads["/generic"][0])
+		}
+		if text := payloads["/slack"][0]["text"]; text != "*Metering alert submissionFailed of tenant 'contoso'*\nazure is down" {
+			t.Fatalf("expected a slack message, got %v", payloads["/slack"][0])
+		}
+		if card := payloads["/teams"][1]; card["@type"] != "MessageCard" || card["text"] != "event expired" {
+			t.Fatalf("expected a teams message card, got %v", card)
+		}
+	})
+
+	t.Run("refuses alerts once closed", func(t *testing.T) {
+		if err := notifier.Close(ctx); err == nil {
+			t.Fatal("expected the notifier to be closed")
+		}
+	})
+}
+
+func TestAlertNotifierFullQueue(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	// the webhook holds the first alert until it is released, so the queue fills up
+	mu := sync.Mutex{}
+	dimensions := []string{}
+	received, released := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		alert := metering.Alert{}
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+
+		mu.Lock()
+		dimensions = append(dimensions, alert.DimensionID)
+		mu.Unlock()
+
+		select {
+		case received <- struct{}{}:
+			<-released
+		default:
+		}
+	}))
+	defer server.Close()
+
+	notifier := metering.NewAlertNotifier(metering.AlertConfiguration{
+		WebhookURL:     server.URL,
+		WebhookTimeout: time.Second,
+		DedupWindow:    time.Hour,
+		QueueSize:      1,
+	}, logger)
+
+	ctx := context.Background()
+	alert := func(dimension string) metering.Alert {
+		return metering.Alert{Kind: metering.AlertEventExpired, TenantID: "contoso", DimensionID: dimension}
+	}
+
+	notifier.Alert(ctx, alert("gpu"))
+	<-received
+	notifier.Alert(ctx, alert("cpu"))
+	// the queue is full, the alert is dropped and raised again once there is room
+	notifier.Alert(ctx, alert("ram"))
+	close(released)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		delivered := len(dimensions)
+		mu.Unlock()
+		if delivered == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	notifier.Alert(ctx, alert("ram"))
+
+	if err := notifier.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"gpu", "cpu", "ram"}, dimensions); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,159 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlanResolver defines the plans of the resources over time, so the usage is billed against the plan of its hour
+type PlanResolver interface {
+	// PlanID returns the plan of the resource at the time, false when no change of plan is known before it
+	PlanID(resourceURI string, at time.Time) (string, bool)
+}
+
+// PlanRecorder defines the recording of the changes of plan, e.g. from the notifications of the marketplace
+type PlanRecorder interface {
+	// RecordPlan records that the resource is billed against the plan from the time
+	RecordPlan(resourceURI string, planID string, from time.Time) error
+}
+
+// PlanHistory defines the history of the changes of plan of the resources
+type PlanHistory interface {
+	PlanResolver
+	PlanRecorder
+	Close() error
+}
+
+// PlanChange represents the change of the plan of a resource
+type PlanChange struct {
+	ResourceURI string    `json:"resourceUri"`
+	PlanID      string    `json:"planId"`
+	From        time.Time `json:"from"`
+}
+
+type planHistory struct {
+	mu      sync.RWMutex
+	file    *os.File
+	changes map[string][]PlanChange
+}
+
+// NewPlanHistory initializes the history of the changes of plan.
+// When the path isn't empty every change is appended to the file, and the changes already there are loaded.
+func NewPlanHistory(path string) (PlanHistory, error) {
+	h := &planHistory{changes: map[string][]PlanChange{}}
+
+	if path == "" {
+		return h, nil
+	}
+
+	if err := h.load(path); err != nil {
+		return nil, fmt.Errorf("could not load plan history %s. Err: %v", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+
+	return h, nil
+}
+
+// PlanID returns the plan of the latest change of the resource that is not after the time
+func (h *planHistory) PlanID(resourceURI string, at time.Time) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.planID(resourceURI, at)
+}
+
+// RecordPlan records the change, unless the resource is already billed against the plan at the time
+func (h *planHistory) RecordPlan(resourceURI string, planID string, from time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, ok := h.planID(resourceURI, from); ok && current == planID {
+		return nil
+	}
+
+	change := PlanChange{ResourceURI: resourceURI, PlanID: planID, From: from.UTC()}
+	if h.file != nil {
+		if err := json.NewEncoder(h.file).Encode(change); err != nil {
+			return err
+		}
+		if err := h.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	h.add(change)
+	return nil
+}
+
+func (h *planHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file != nil {
+		return h.file.Close()
+	}
+	return nil
+}
+
+func (h *planHistory) planID(resourceURI string, at time.Time) (string, bool) {
+	changes := h.changes[strings.ToLower(resourceURI)]
+
+	// the changes are sorted by time, so the plan is the one of the last change not after the time
+	i := sort.Search(len(changes), func(i int) bool { return changes[i].From.After(at) })
+	if i == 0 {
+		return "", false
+	}
+	return changes[i-1].PlanID, true
+}
+
+// add inserts the change in the order of time, the resources are compared without case like Azure does
+func (h *planHistory) add(change PlanChange) {
+	key := strings.ToLower(change.ResourceURI)
+	changes := h.changes[key]
+
+	i := sort.Search(len(changes), func(i int) bool { return changes[i].From.After(change.From) })
+	changes = append(changes, PlanChange{})
+	copy(changes[i+1:], changes[i:])
+	changes[i] = change
+
+	h.changes[key] = changes
+}
+
+func (h *planHistory) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		change := PlanChange{}
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		h.add(change)
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,62 @@
+package metering_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+func TestPlanHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plans.ndjson")
+	resource := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Solutions/applications/app"
+	upgradedAt := time.Date(2024, 5, 10, 14, 0, 0, 0, time.UTC)
+
+	history, err := metering.NewPlanHistory(path)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	// the changes may be recorded out of order, and a change to the same plan is ignored
+	for _, change := range []metering.PlanChange{
+		{PlanID: "platinum", From: upgradedAt},
+		{PlanID: "gold", From: upgradedAt.AddDate(0, 0, -9)},
+		{PlanID: "platinum", From: upgradedAt.Add(time.Hour)},
+	} {
+		if err := history.RecordPlan(resource, change.PlanID, change.From); err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+	}
+
+	if err := history.Close(); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	reloaded, err := metering.NewPlanHistory(path)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+	defer reloaded.Close()
+
+	configuration := metering.Configuration{ResourceUri: resource, PlanId: "basic", Plans: reloaded}
+
+	tt := []struct {
+		name     string
+		startAt  time.Time
+		expected string
+	}{
+		{name: "before any change", startAt: upgradedAt.AddDate(0, -1, 0), expected: "basic"},
+		{name: "hour before the upgrade", startAt: upgradedAt.Add(-time.Hour), expected: "gold"},
+		{name: "hour of the upgrade", startAt: upgradedAt, expected: "platinum"},
+		{name: "after the upgrade", startAt: upgradedAt.AddDate(0, 0, 1), expected: "platinum"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if planID := configuration.PlanAt(tc.startAt); planID != tc.expected {
+				t.Fatalf("should be %s, got %s", tc.expected, planID)
+			}
+		})
+	}
+}
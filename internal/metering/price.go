@@ -0,0 +1,220 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+// PriceConfiguration represents the prices of the dimensions of each plan, which the estimates are based on
+type PriceConfiguration struct {
+	File string `envconfig:"METERING_PRICES_FILE" default:""`
+
+	Table PriceTable `ignored:"true"`
+}
+
+// PriceTable represents the prices of the dimensions of each plan, in a single currency
+type PriceTable struct {
+	Currency string                      `json:"currency"`
+	Plans    map[string]map[string]Price `json:"plans"`
+}
+
+// Price represents the price of a dimension, where the included quantity of the billing period is free
+type Price struct {
+	UnitPrice        float64 `json:"unitPrice"`
+	IncludedQuantity float64 `json:"includedQuantity"`
+}
+
+// EstimateSource defines which usage events the estimate is based on
+type EstimateSource string
+
+// Supported estimate sources
+const (
+	// EstimateSourceAccepted counts the events accepted by azure, which are the ones billed
+	EstimateSourceAccepted EstimateSource = "accepted"
+	// EstimateSourceSubmitted counts every event sent to azure, whatever its status
+	EstimateSourceSubmitted EstimateSource = "submitted"
+	// EstimateSourceRequest counts the events of the request, which weren't sent to azure
+	EstimateSourceRequest EstimateSource = "request"
+)
+
+// Estimate represents the cost of the usage of a period, itemized by plan and dimension.
+// The plan is the one the tenant is billed for at the end of the period.
+type Estimate struct {
+	TenantID string         `json:"tenantId,omitempty"`
+	PlanID   string         `json:"planId"`
+	Currency string         `json:"currency"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Source   EstimateSource `json:"source"`
+	Items    []EstimateItem `json:"items"`
+	Total    float64        `json:"total"`
+}
+
+// EstimateRequest represents the usage events whose cost is estimated, instead of the recorded ones
+type EstimateRequest struct {
+	Events []coreMetering.UsageEvent `json:"events"`
+}
+
+// EstimateItem represents the cost of the usage of a dimension billed against a plan,
+// it isn't priced when the plan has no price for it.
+// The included quantity is the sum of the included quantity of each billing month of the period,
+// prorated to the part of the month in the period.
+type EstimateItem struct {
+	PlanID           string  `json:"planId"`
+	DimensionID      string  `json:"dimensionId"`
+	Quantity         float64 `json:"quantity"`
+	IncludedQuantity float64 `json:"includedQuantity"`
+	BillableQuantity float64 `json:"billableQuantity"`
+	UnitPrice        float64 `json:"unitPrice"`
+	Amount           float64 `json:"amount"`
+	Priced           bool    `json:"priced"`
+}
+
+// LoadFromEnvVars reads the env vars of the prices and the price table from the configured file.
+func (c *PriceConfiguration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	if c.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &c.Table); err != nil {
+		return fmt.Errorf("could not parse prices file %s. Err: %v", c.File, err)
+	}
+
+	return c.Table.validate()
+}
+
+func (t PriceTable) validate() error {
+	if len(t.Plans) > 0 && t.Currency == "" {
+		return fmt.Errorf("the prices must have a currency")
+	}
+
+	for planID, dimensions := range t.Plans {
+		for dimension, price := range dimensions {
+			if price.UnitPrice < 0 || price.IncludedQuantity < 0 {
+				return fmt.Errorf("price of dimension '%s' of plan '%s' must not be negative", dimension, planID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s EstimateSource) validate() error {
+	switch s {
+	case EstimateSourceAccepted, EstimateSourceSubmitted:
+		return nil
+	default:
+		return fmt.Errorf("invalid estimate source '%s'", s)
+	}
+}
+
+// counts returns true when the recorded event is part of the usage of the source
+func (s EstimateSource) counts(event SinkEvent) bool {
+	if s == EstimateSourceAccepted {
+		return event.Status == StatusAccepted
+	}
+	return event.Status != sinkStatusFailed
+}
+
+// estimateKey identifies the usage of a dimension billed against a plan in a billing month
+type estimateKey struct {
+	month       time.Time
+	planID      string
+	dimensionID string
+}
+
+// Estimate sums the quantities of the events of each dimension in the period and prices them with the plan
+// the events were billed against, or the plan at their start when it wasn't recorded.
+// The included quantity is deducted from the quantity of each billing month, in UTC, in proportion to the part
+// of the month in the period, so the estimate of a partial month isn't reduced by the quantity of the whole month.
+func (t PriceTable) Estimate(
+	planAt func(time.Time) string, source EstimateSource, from, to time.Time, events []SinkEvent,
+) Estimate {
+	quantities := map[estimateKey]float64{}
+	for _, event := range events {
+		if !source.counts(event) {
+			continue
+		}
+
+		planID := event.PlanID
+		if planID == "" {
+			planID = planAt(event.StartAt)
+		}
+		startAt := event.StartAt.UTC()
+		key := estimateKey{
+			month:       time.Date(startAt.Year(), startAt.Month(), 1, 0, 0, 0, 0, time.UTC),
+			planID:      planID,
+			dimensionID: event.DimensionID,
+		}
+		quantities[key] += float64(event.Quantity)
+	}
+
+	items := map[estimateKey]*EstimateItem{}
+	for key, quantity := range quantities {
+		itemKey := estimateKey{planID: key.planID, dimensionID: key.dimensionID}
+		item, ok := items[itemKey]
+		if !ok {
+			item = &EstimateItem{PlanID: key.planID, DimensionID: key.dimensionID}
+			items[itemKey] = item
+		}
+		item.Quantity += quantity
+
+		if price, ok := t.Plans[key.planID][key.dimensionID]; ok {
+			included := price.IncludedQuantity * monthShare(key.month, from, to)
+			billable := max(quantity-included, 0)
+			item.Priced = true
+			item.UnitPrice = price.UnitPrice
+			item.IncludedQuantity += included
+			item.BillableQuantity += billable
+			item.Amount += billable * price.UnitPrice
+		}
+	}
+
+	estimate := Estimate{Currency: t.Currency, Source: source, Items: []EstimateItem{}}
+	for _, item := range items {
+		estimate.Items = append(estimate.Items, *item)
+		estimate.Total += item.Amount
+	}
+
+	sort.Slice(estimate.Items, func(i, j int) bool {
+		if estimate.Items[i].PlanID != estimate.Items[j].PlanID {
+			return estimate.Items[i].PlanID < estimate.Items[j].PlanID
+		}
+		return estimate.Items[i].DimensionID < estimate.Items[j].DimensionID
+	})
+
+	return estimate
+}
+
+// monthShare returns the part of the billing month that is in the period
+func monthShare(month, from, to time.Time) float64 {
+	start, end := month, month.AddDate(0, 1, 0)
+	if from.After(start) {
+		start = from
+	}
+	if to.Before(end) {
+		end = to
+	}
+
+	overlap := end.Sub(start)
+	if overlap <= 0 {
+		return 0
+	}
+	return float64(overlap) / float64(month.AddDate(0, 1, 0).Sub(month))
+}
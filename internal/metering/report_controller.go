@@ -0,0 +1,270 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+)
+
+var errInvalidPeriod = errors.New("invalid period")
+
+// ReportController defines the rest controller of the reports on the recorded usage
+type ReportController struct {
+	logger        logging.Logger
+	configuration config.RESTControllerConfiguration
+	accounts      Accounts
+	prices        PriceTable
+	guard         auth.Guard
+}
+
+// NewReportController initializes the report controller
+func NewReportController(
+	logger logging.Logger,
+	accounts Accounts,
+	prices PriceTable,
+	guard auth.Guard,
+	configuration config.RESTControllerConfiguration,
+) ReportController {
+	return ReportController{
+		logger:        logger,
+		configuration: configuration,
+		accounts:      accounts,
+		prices:        prices,
+		guard:         guard,
+	}
+}
+
+// Boot ...
+func (r ReportController) Boot(s server.Server) {
+	for _, prefix := range []string{"/metering", "/tenants/:tenantId/metering"} {
+		group := s.Router().Group(prefix, r.guard.Require(auth.ScopeMeteringRead))
+		group.GET("/estimate", r.estimate())
+		group.POST("/estimate", r.estimateRequest())
+		group.GET("/summary", r.summary())
+	}
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r ReportController) Describe(spec *openapi.Spec) {
+	period := openapi3.Parameters{
+		{Value: openapi3.NewQueryParameter("month").
+			WithDescription("Billing month of the period in UTC, e.g. 2024-05, instead of from and to").
+			WithSchema(openapi3.NewStringSchema().WithPattern(`^\d{4}-\d{2}$`))},
+		{Value: openapi3.NewQueryParameter("from").
+			WithDescription("Start of the period, inclusive, the start of the current month by default").
+			WithSchema(openapi3.NewDateTimeSchema())},
+		{Value: openapi3.NewQueryParameter("to").
+			WithDescription("End of the period, exclusive, the start of the next month by default").
+			WithSchema(openapi3.NewDateTimeSchema())},
+	}
+	source := &openapi3.ParameterRef{Value: openapi3.NewQueryParameter("source").
+		WithDescription("Usage events the estimate is based on").
+		WithSchema(openapi3.NewStringSchema().
+			WithEnum(string(EstimateSourceAccepted), string(EstimateSourceSubmitted)).
+			WithDefault(string(EstimateSourceAccepted)))}
+
+	granularity := &openapi3.ParameterRef{Value: openapi3.NewQueryParameter("granularity").
+		WithDescription("Length of the buckets of the summary").
+		WithSchema(openapi3.NewStringSchema().
+			WithEnum(string(GranularityDay), string(GranularityHour)).
+			WithDefault(string(GranularityDay)))}
+	byStatus := &openapi3.ParameterRef{Value: openapi3.NewQueryParameter("byStatus").
+		WithDescription("Breaks the quantities down by the status azure returned").
+		WithSchema(openapi3.NewBoolSchema().WithDefault(false))}
+
+	for _, route := range tenantRoutes() {
+		spec.AddOperation(http.MethodGet, route.prefix+"/estimate", openapi.Operation{
+			ID:         "estimateUsageCost" + route.suffix,
+			Summary:    "Estimates the cost of the usage recorded in a period with the prices of the plans it was billed against",
+			Parameters: append(append(openapi3.Parameters{source}, period...), route.parameters...),
+			Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(Estimate{}, "planId", "items", "total")},
+		})
+
+		spec.AddOperation(http.MethodPost, route.prefix+"/estimate", openapi.Operation{
+			ID:         "estimateRequestedUsageCost" + route.suffix,
+			Summary:    "Estimates the cost of the usage events of the request, which must start in the period",
+			Parameters: append(append(openapi3.Parameters{}, period...), route.parameters...),
+			Request:    spec.Schema(EstimateRequest{}, "events"),
+			Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(Estimate{}, "planId", "items", "total")},
+		})
+
+		spec.AddOperation(http.MethodGet, route.prefix+"/summary", openapi.Operation{
+			ID:         "summarizeUsage" + route.suffix,
+			Summary:    "Sums the usage submitted in a period by plan and dimension, for the period and each day or hour",
+			Parameters: append(append(openapi3.Parameters{granularity, byStatus}, period...), route.parameters...),
+			Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(Summary{}, "totals", "buckets")},
+		})
+	}
+}
+
+func (r ReportController) estimate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenantID := tenantID(ctx)
+
+		from, to, err := period(ctx, time.Now().UTC())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		source := EstimateSource(ctx.DefaultQuery("source", string(EstimateSourceAccepted)))
+		if err := source.validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		ledger, err := r.accounts.Ledger(tenantID)
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		r.respondEstimate(ctx, tenantID, source, from, to, ledger.Entries(from, to))
+	}
+}
+
+// estimateRequest estimates the cost of the usage events of the request, e.g. the usage a customer plans
+func (r ReportController) estimateRequest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenantID := tenantID(ctx)
+
+		from, to, err := period(ctx, time.Now().UTC())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		request := EstimateRequest{}
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		events := make([]SinkEvent, 0, len(request.Events))
+		for _, event := range request.Events {
+			if event.StartAt.Before(from) || !event.StartAt.Before(to) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf(
+					"%v, the event of dimension '%s' at %s isn't in the period", errInvalidPeriod, event.DimensionID,
+					event.StartAt.Format(time.RFC3339))})
+				return
+			}
+			events = append(events, SinkEvent{UsageEvent: event, Status: StatusAccepted})
+		}
+
+		r.respondEstimate(ctx, tenantID, EstimateSourceRequest, from, to, events)
+	}
+}
+
+// respondEstimate prices the events of the period with the plans of the tenant
+func (r ReportController) respondEstimate(
+	ctx *gin.Context, tenantID string, source EstimateSource, from, to time.Time, events []SinkEvent,
+) {
+	planID, err := r.accounts.PlanAt(tenantID, to.Add(-time.Nanosecond))
+	if err != nil {
+		r.failed(ctx, err)
+		return
+	}
+
+	// the tenant is known, the plans of the events can't fail
+	planAt := func(at time.Time) string {
+		planID, _ := r.accounts.PlanAt(tenantID, at)
+		return planID
+	}
+
+	estimate := r.prices.Estimate(planAt, source, from, to, events)
+	estimate.TenantID = tenantID
+	estimate.PlanID = planID
+	estimate.From, estimate.To = from, to
+
+	ctx.JSON(http.StatusOK, estimate)
+}
+
+func (r ReportController) summary() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenantID := tenantID(ctx)
+
+		from, to, err := period(ctx, time.Now().UTC())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		granularity := Granularity(ctx.DefaultQuery("granularity", string(GranularityDay)))
+		if err := granularity.validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		byStatus, err := strconv.ParseBool(ctx.DefaultQuery("byStatus", "false"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "byStatus must be a boolean"})
+			return
+		}
+
+		ledger, err := r.accounts.Ledger(tenantID)
+		if err != nil {
+			r.failed(ctx, err)
+			return
+		}
+
+		summary := Summarize(ledger.Entries(from, to), granularity, byStatus)
+		summary.TenantID = tenantID
+		summary.From, summary.To = from, to
+
+		ctx.JSON(http.StatusOK, summary)
+	}
+}
+
+func (r ReportController) failed(ctx *gin.Context, err error) {
+	correlation.Logger(ctx, r.logger).Errorf("failed with error %v", err)
+
+	ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
+}
+
+// period returns the billing month of the month query parameter, or the from and to query parameters,
+// the current billing month of now by default. The billing months start at midnight UTC.
+func period(ctx *gin.Context, now time.Time) (time.Time, time.Time, error) {
+	if month := ctx.Query("month"); month != "" {
+		if ctx.Query("from") != "" || ctx.Query("to") != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w, month can't be combined with from or to", errInvalidPeriod)
+		}
+
+		from, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w, month must be YYYY-MM", errInvalidPeriod)
+		}
+		return from, from.AddDate(0, 1, 0), nil
+	}
+
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	for name, value := range map[string]*time.Time{"from": &from, "to": &to} {
+		if query := ctx.Query(name); query != "" {
+			t, err := time.Parse(time.RFC3339, query)
+			if err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("%w, %s must be RFC 3339", errInvalidPeriod, name)
+			}
+			*value = t.UTC()
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w, from must be before to", errInvalidPeriod)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,221 @@
+package metering_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// accounts is a single tenant with its ledger and plan, billed against the previous plan before the change
+type accounts struct {
+	tenantID  string
+	planID    string
+	ledger    metering.Ledger
+	changedAt time.Time
+	previous  string
+}
+
+func (a accounts) Ledger(tenantID string) (metering.Ledger, error) {
+	if tenantID != a.tenantID {
+		return nil, fmt.Errorf("%w '%s'", metering.ErrUnknownTenant, tenantID)
+	}
+	return a.ledger, nil
+}
+
+func (a accounts) PlanAt(tenantID string, at time.Time) (string, error) {
+	if tenantID != a.tenantID {
+		return "", fmt.Errorf("%w '%s'", metering.ErrUnknownTenant, tenantID)
+	}
+	if at.Before(a.changedAt) {
+		return a.previous, nil
+	}
+	return a.planID, nil
+}
+
+func TestEstimate(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	event := func(dimension string, quantity float32, startAt time.Time, status string) metering.SinkEvent {
+		return metering.SinkEvent{
+			UsageEvent: coreMetering.UsageEvent{DimensionID: dimension, Quantity: quantity, StartAt: startAt},
+			Status:     status,
+		}
+	}
+	// billed is an event whose plan was recorded, which takes precedence over the plan history
+	billed := func(dimension string, quantity float32, startAt time.Time, planID string) metering.SinkEvent {
+		event := event(dimension, quantity, startAt, metering.StatusAccepted)
+		event.PlanID = planID
+		return event
+	}
+
+	ledger, err := metering.NewLedger("", 2160*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Send(context.Background(), []metering.SinkEvent{
+		event("gpu", 80, month, metering.StatusAccepted),
+		event("gpu", 40, month.Add(time.Hour), metering.StatusAccepted),
+		event("gpu", 10, month.Add(2*time.Hour), "Expired"),
+		event("cpu", 5, month.Add(time.Hour), metering.StatusAccepted),
+		billed("gpu", 1000, month.AddDate(0, -1, 0), "plan"),
+		event("cpu", 7, month.AddDate(0, -1, 0), metering.StatusAccepted),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	prices := metering.PriceTable{
+		Currency: "USD",
+		Plans: map[string]map[string]metering.Price{
+			"plan":   {"gpu": {UnitPrice: 2, IncludedQuantity: 100}},
+			"legacy": {"cpu": {UnitPrice: 1}},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	metering.NewReportController(logger, accounts{tenantID: "contoso", planID: "plan", ledger: ledger, changedAt: month, previous: "legacy"}, prices,
+		auth.NewGuard(logger, nil), config.RESTControllerConfiguration{}).Boot(httpServer)
+
+	serve := func(request *http.Request) (int, metering.Estimate) {
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder, request)
+
+		estimate := metering.Estimate{}
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &estimate); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return recorder.Code, estimate
+	}
+	estimate := func(path string) (int, metering.Estimate) {
+		return serve(httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	t.Run("prices the accepted usage of the current month", func(t *testing.T) {
+		code, got := estimate("/tenants/contoso/metering/estimate")
+		if code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		expected := []metering.EstimateItem{
+			{PlanID: "plan", DimensionID: "cpu", Quantity: 5},
+			{PlanID: "plan", DimensionID: "gpu", Quantity: 120, IncludedQuantity: 100, BillableQuantity: 20, UnitPrice: 2,
+				Amount: 40, Priced: true},
+		}
+		if diff := cmp.Diff(expected, got.Items); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if got.Total != 40 || got.Currency != "USD" || !got.From.Equal(month) {
+			t.Fatalf("unexpected estimate %+v", got)
+		}
+	})
+
+	t.Run("prices the submitted usage", func(t *testing.T) {
+		_, got := estimate("/tenants/contoso/metering/estimate?source=submitted")
+		if got.Total != 60 {
+			t.Fatalf("expected a total of 60, got %v", got.Total)
+		}
+	})
+
+	t.Run("prices each billing month with the plan of its usage", func(t *testing.T) {
+		from, to := month.AddDate(0, -1, 0).Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339)
+		_, got := estimate("/tenants/contoso/metering/estimate?from=" + from + "&to=" + to)
+
+		expected := []metering.EstimateItem{
+			{PlanID: "legacy", DimensionID: "cpu", Quantity: 7, BillableQuantity: 7, UnitPrice: 1, Amount: 7, Priced: true},
+			{PlanID: "plan", DimensionID: "cpu", Quantity: 5},
+			{PlanID: "plan", DimensionID: "gpu", Quantity: 1120, IncludedQuantity: 200, BillableQuantity: 920, UnitPrice: 2,
+				Amount: 1840, Priced: true},
+		}
+		if diff := cmp.Diff(expected, got.Items); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if got.Total != 1847 || got.PlanID != "plan" {
+			t.Fatalf("unexpected estimate %+v", got)
+		}
+	})
+
+	t.Run("prorates the included quantity of a partial month", func(t *testing.T) {
+		half := month.AddDate(0, 1, 0).Sub(month) / 2
+		from, to := month.Format(time.RFC3339), month.Add(half).Format(time.RFC3339)
+		_, got := estimate("/tenants/contoso/metering/estimate?from=" + from + "&to=" + to)
+
+		expected := []metering.EstimateItem{
+			{PlanID: "plan", DimensionID: "cpu", Quantity: 5},
+			{PlanID: "plan", DimensionID: "gpu", Quantity: 120, IncludedQuantity: 50, BillableQuantity: 70, UnitPrice: 2,
+				Amount: 140, Priced: true},
+		}
+		if diff := cmp.Diff(expected, got.Items); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("prices the usage of the request", func(t *testing.T) {
+		body := fmt.Sprintf(`{"events":[{"dimensionId":"gpu","quantity":150,"startAt":%q}]}`,
+			month.Add(time.Hour).Format(time.RFC3339))
+		code, got := serve(httptest.NewRequest(http.MethodPost, "/tenants/contoso/metering/estimate",
+			strings.NewReader(body)))
+		if code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		expected := []metering.EstimateItem{
+			{PlanID: "plan", DimensionID: "gpu", Quantity: 150, IncludedQuantity: 100, BillableQuantity: 50, UnitPrice: 2,
+				Amount: 100, Priced: true},
+		}
+		if diff := cmp.Diff(expected, got.Items); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+		if got.Source != metering.EstimateSourceRequest {
+			t.Fatalf("expected the source of the request, got %s", got.Source)
+		}
+	})
+
+	t.Run("rejects the usage of the request outside the period", func(t *testing.T) {
+		body := fmt.Sprintf(`{"events":[{"dimensionId":"gpu","quantity":1,"startAt":%q}]}`,
+			month.AddDate(0, -1, 0).Format(time.RFC3339))
+		code, _ := serve(httptest.NewRequest(http.MethodPost, "/tenants/contoso/metering/estimate",
+			strings.NewReader(body)))
+		if code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("rejects invalid periods", func(t *testing.T) {
+		from := month.Format(time.RFC3339)
+		if code, _ := estimate("/tenants/contoso/metering/estimate?from=" + from + "&to=" + from); code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		if code, _ := estimate("/metering/estimate"); code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+)
+
+// Sink defines a secondary destination for the usage events sent to the marketplace
+type Sink interface {
+	Name() string
+	Send(context.Context, []SinkEvent) error
+	Close() error
+}
+
+// SinkEvent represents an usage event delivered to a secondary sink
+type SinkEvent struct {
+	coreMetering.UsageEvent
+	TenantID     string    `json:"tenantId,omitempty"`
+	PlanID       string    `json:"planId,omitempty"`
+	UsageEventID string    `json:"usageEventId,omitempty"`
+	Status       string    `json:"status"`
+	RecordedAt   time.Time `json:"recordedAt"`
+}
+
+// NewSinks initializes all the sinks enabled in the configuration, which receive the events of its statuses
+func NewSinks(config SinkConfiguration) ([]Sink, error) {
+	sinks := []Sink{}
+
+	if config.WebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(config.WebhookURL, config.WebhookHeaders, config.WebhookTimeout))
+	}
+
+	if config.FilePath != "" {
+		fileSink, err := NewFileSink(config.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fileSink)
+	}
+
+	for i, sink := range sinks {
+		sinks[i] = statusSink{Sink: sink, statuses: config.Statuses}
+	}
+
+	return sinks, nil
+}
+
+// statusSink delivers to a sink only the events with one of the statuses
+type statusSink struct {
+	Sink
+	statuses []string
+}
+
+func (s statusSink) Send(ctx context.Context, events []SinkEvent) error {
+	selected := make([]SinkEvent, 0, len(events))
+	for _, event := range events {
+		if slices.Contains(s.statuses, event.Status) {
+			selected = append(selected, event)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	return s.Sink.Send(ctx, selected)
+}
+
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	timeout time.Duration
+	pl      coreHTTP.Pipeline
+}
+
+// NewWebhookSink initializes a sink that posts the events as JSON to an HTTP endpoint
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) Sink {
+	return webhookSink{
+		url:     url,
+		headers: headers,
+		timeout: timeout,
+		pl:      coreHTTP.NewPipeline(),
+	}
+}
+
+func (s webhookSink) Name() string {
+	return "webhook"
+}
+
+// Send posts the events to the webhook, any status code other than 2xx is considered a failure
+func (s webhookSink) Send(ctx context.Context, events []SinkEvent) error {
+	tCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := coreHTTP.NewRequest(tCtx, http.MethodPost, s.url)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	if err := req.EncodeAsJSON(struct {
+		Events []SinkEvent `json:"events"`
+	}{Events: events}); err != nil {
+		return err
+	}
+
+	resp, err := s.pl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return invalidStatusCodeError(resp.Response)
+	}
+
+	return nil
+}
+
+func (s webhookSink) Close() error {
+	return nil
+}
+
+type fileSink struct {
+	path string
+	mu   *sync.Mutex
+	file *os.File
+}
+
+// NewFileSink initializes a sink that appends the events to a file, one JSON document per line (NDJSON)
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return fileSink{
+		path: path,
+		mu:   &sync.Mutex{},
+		file: file,
+	}, nil
+}
+
+func (s fileSink) Name() string {
+	return "file"
+}
+
+// Send appends the events to the file
+func (s fileSink) Send(_ context.Context, events []SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoder := json.NewEncoder(s.file)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return s.file.Sync()
+}
+
+func (s fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
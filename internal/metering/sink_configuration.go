@@ -0,0 +1,51 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// SinkConfiguration represents the configuration for the secondary sinks of the metering fan-out.
+type SinkConfiguration struct {
+	WebhookURL     string            `envconfig:"METERING_SINK_WEBHOOK_URL" default:""`
+	WebhookHeaders map[string]string `envconfig:"METERING_SINK_WEBHOOK_HEADERS" default:""`
+	WebhookTimeout time.Duration     `envconfig:"METERING_SINK_WEBHOOK_TIMEOUT" default:"10s"`
+	FilePath       string            `envconfig:"METERING_SINK_FILE_PATH" default:""`
+	// Statuses are the statuses of the events delivered to the webhook and file sinks, the ledger records all of them.
+	// The default ones don't count twice the usage the callers retry.
+	Statuses        []string      `envconfig:"METERING_SINK_STATUSES" default:"Accepted"`
+	QueueSize       int           `envconfig:"METERING_SINK_QUEUE_SIZE" default:"1000"`
+	MaxAttempts     int           `envconfig:"METERING_SINK_MAX_ATTEMPTS" default:"5"`
+	RetryBackoff    time.Duration `envconfig:"METERING_SINK_RETRY_BACKOFF" default:"1s"`
+	MaxRetryBackoff time.Duration `envconfig:"METERING_SINK_MAX_RETRY_BACKOFF" default:"1m"`
+	LedgerPath      string        `envconfig:"METERING_LEDGER_PATH" default:""`
+	LedgerRetention time.Duration `envconfig:"METERING_LEDGER_RETENTION" default:"2160h"`
+}
+
+// LoadFromEnvVars reads all env vars required for the metering sinks.
+func (c *SinkConfiguration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	return c.validate()
+}
+
+func (c SinkConfiguration) validate() error {
+	if c.QueueSize <= 0 || c.MaxAttempts <= 0 {
+		return fmt.Errorf("METERING_SINK_QUEUE_SIZE and METERING_SINK_MAX_ATTEMPTS must be positive")
+	}
+
+	for _, status := range c.Statuses {
+		switch status {
+		case StatusAccepted, StatusDuplicate, StatusExpired, StatusSkipped, StatusUnknown, StatusRejected, sinkStatusFailed:
+		default:
+			return fmt.Errorf("invalid sink status '%s'", status)
+		}
+	}
+
+	return nil
+}
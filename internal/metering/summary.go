@@ -0,0 +1,136 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Granularity defines the length of the buckets of a usage summary, which start at UTC boundaries
+type Granularity string
+
+// Supported granularities
+const (
+	GranularityDay  Granularity = "day"
+	GranularityHour Granularity = "hour"
+)
+
+// Summary represents the usage submitted to azure in a period, by plan and dimension
+type Summary struct {
+	TenantID    string          `json:"tenantId,omitempty"`
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Granularity Granularity     `json:"granularity"`
+	Totals      []SummaryItem   `json:"totals"`
+	Buckets     []SummaryBucket `json:"buckets"`
+}
+
+// SummaryBucket represents the usage of the events that started in a day or an hour
+type SummaryBucket struct {
+	Start time.Time     `json:"start"`
+	Items []SummaryItem `json:"items"`
+}
+
+// SummaryItem represents the usage of a dimension billed against a plan,
+// the plan is empty for the events recorded before the ledger kept it
+type SummaryItem struct {
+	PlanID      string  `json:"planId"`
+	DimensionID string  `json:"dimensionId"`
+	Quantity    float64 `json:"quantity"`
+	Events      int     `json:"events"`
+	// Statuses breaks the quantity down by the status azure returned, when requested
+	Statuses map[string]float64 `json:"statuses,omitempty"`
+}
+
+func (g Granularity) validate() error {
+	switch g {
+	case GranularityDay, GranularityHour:
+		return nil
+	default:
+		return fmt.Errorf("invalid granularity '%s'", g)
+	}
+}
+
+func (g Granularity) duration() time.Duration {
+	if g == GranularityHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Summarize sums the quantities of the events submitted to azure by plan and dimension,
+// for the whole period and for each day or hour, optionally by status
+func Summarize(events []SinkEvent, granularity Granularity, byStatus bool) Summary {
+	summary := Summary{Granularity: granularity, Totals: []SummaryItem{}, Buckets: []SummaryBucket{}}
+
+	totals := summaryItems{}
+	buckets := map[time.Time]summaryItems{}
+	for _, event := range events {
+		// the skipped events never reached azure
+		if event.Status == StatusSkipped || !EstimateSourceSubmitted.counts(event) {
+			continue
+		}
+
+		start := event.StartAt.UTC().Truncate(granularity.duration())
+		if _, ok := buckets[start]; !ok {
+			buckets[start] = summaryItems{}
+		}
+
+		totals.add(event, byStatus)
+		buckets[start].add(event, byStatus)
+	}
+
+	summary.Totals = totals.sorted()
+	for start, items := range buckets {
+		summary.Buckets = append(summary.Buckets, SummaryBucket{Start: start, Items: items.sorted()})
+	}
+	sort.Slice(summary.Buckets, func(i, j int) bool {
+		return summary.Buckets[i].Start.Before(summary.Buckets[j].Start)
+	})
+
+	return summary
+}
+
+type summaryKey struct {
+	planID      string
+	dimensionID string
+}
+
+type summaryItems map[summaryKey]*SummaryItem
+
+func (s summaryItems) add(event SinkEvent, byStatus bool) {
+	key := summaryKey{planID: event.PlanID, dimensionID: event.DimensionID}
+
+	item, ok := s[key]
+	if !ok {
+		item = &SummaryItem{PlanID: event.PlanID, DimensionID: event.DimensionID}
+		if byStatus {
+			item.Statuses = map[string]float64{}
+		}
+		s[key] = item
+	}
+
+	item.Quantity += float64(event.Quantity)
+	item.Events++
+	if byStatus {
+		item.Statuses[event.Status] += float64(event.Quantity)
+	}
+}
+
+// sorted returns the items sorted by plan and dimension
+func (s summaryItems) sorted() []SummaryItem {
+	items := make([]SummaryItem, 0, len(s))
+	for _, item := range s {
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].PlanID != items[j].PlanID {
+			return items[i].PlanID < items[j].PlanID
+		}
+		return items[i].DimensionID < items[j].DimensionID
+	})
+
+	return items
+}
@@ -0,0 +1,141 @@
+package metering_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+func TestSummary(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	month := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	event := func(planID, dimension string, quantity float32, startAt time.Time, status string) metering.SinkEvent {
+		return metering.SinkEvent{
+			PlanID:     planID,
+			UsageEvent: coreMetering.UsageEvent{DimensionID: dimension, Quantity: quantity, StartAt: startAt},
+			Status:     status,
+		}
+	}
+
+	ledger, err := metering.NewLedger("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Send(context.Background(), []metering.SinkEvent{
+		event("gold", "gpu", 8, month, metering.StatusAccepted),
+		event("gold", "gpu", 4, month.Add(time.Hour), "Duplicate"),
+		event("gold", "cpu", 2, month.Add(time.Hour), metering.StatusAccepted),
+		event("platinum", "gpu", 1, month.Add(26*time.Hour), metering.StatusAccepted),
+		event("platinum", "gpu", 50, month.Add(26*time.Hour), "Failed"),
+		event("platinum", "gpu", 60, month.Add(26*time.Hour), metering.StatusSkipped),
+		event("gold", "gpu", 1000, month.AddDate(0, -1, 0), metering.StatusAccepted),
+		event("gold", "gpu", 1000, month.AddDate(0, 1, 0), metering.StatusAccepted),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	metering.NewReportController(logger, accounts{tenantID: "contoso", planID: "gold", ledger: ledger},
+		metering.PriceTable{}, auth.NewGuard(logger, nil), config.RESTControllerConfiguration{}).Boot(httpServer)
+
+	summarize := func(path string) (int, metering.Summary) {
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		summary := metering.Summary{}
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &summary); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return recorder.Code, summary
+	}
+
+	t.Run("sums the submitted usage of the billing month by day", func(t *testing.T) {
+		code, got := summarize("/tenants/contoso/metering/summary?month=2024-05")
+		if code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, code)
+		}
+
+		expected := metering.Summary{
+			TenantID:    "contoso",
+			From:        month,
+			To:          month.AddDate(0, 1, 0),
+			Granularity: metering.GranularityDay,
+			Totals: []metering.SummaryItem{
+				{PlanID: "gold", DimensionID: "cpu", Quantity: 2, Events: 1},
+				{PlanID: "gold", DimensionID: "gpu", Quantity: 12, Events: 2},
+				{PlanID: "platinum", DimensionID: "gpu", Quantity: 1, Events: 1},
+			},
+			Buckets: []metering.SummaryBucket{
+				{Start: month, Items: []metering.SummaryItem{
+					{PlanID: "gold", DimensionID: "cpu", Quantity: 2, Events: 1},
+					{PlanID: "gold", DimensionID: "gpu", Quantity: 12, Events: 2},
+				}},
+				{Start: month.AddDate(0, 0, 1), Items: []metering.SummaryItem{
+					{PlanID: "platinum", DimensionID: "gpu", Quantity: 1, Events: 1},
+				}},
+			},
+		}
+		if diff := cmp.Diff(expected, got); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("breaks the hours down by status", func(t *testing.T) {
+		_, got := summarize("/tenants/contoso/metering/summary?month=2024-05&granularity=hour&byStatus=true")
+
+		expected := []metering.SummaryItem{
+			{PlanID: "gold", DimensionID: "cpu", Quantity: 2, Events: 1, Statuses: map[string]float64{"Accepted": 2}},
+			{PlanID: "gold", DimensionID: "gpu", Quantity: 4, Events: 1, Statuses: map[string]float64{"Duplicate": 4}},
+		}
+		if len(got.Buckets) != 3 {
+			t.Fatalf("expected 3 buckets, got %+v", got.Buckets)
+		}
+		if diff := cmp.Diff(expected, got.Buckets[1].Items); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		for _, query := range []string{
+			"month=2024-13",
+			"month=2024-05&from=2024-05-01T00:00:00Z",
+			"granularity=week",
+			"byStatus=maybe",
+		} {
+			if code, _ := summarize("/tenants/contoso/metering/summary?" + query); code != http.StatusBadRequest {
+				t.Fatalf("%s: expected %d, got %d", query, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		if code, _ := summarize("/metering/summary"); code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+// Package metering provides objects to interact with metering API
+package metering
+
+import (
+	"context"
+	"errors"
+)
+
+// TenantHeader is the header that identifies the tenant of a request, alternatively to the tenant path parameter
+const TenantHeader = "X-Tenant-Id"
+
+// Errors returned by the clients that route the usage events of multiple tenants
+var (
+	ErrUnknownTenant = errors.New("unknown tenant")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of the context with the tenant of the usage events
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant of the usage events, which is empty for the default tenant
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
@@ -0,0 +1,39 @@
+// Package openapi provides objects to describe the adapter API and validate requests against it
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// ResponseValidation defines what happens when a response doesn't match the specification
+type ResponseValidation string
+
+// Supported response validation modes
+const (
+	ResponseValidationOff     ResponseValidation = "off"
+	ResponseValidationLog     ResponseValidation = "log"
+	ResponseValidationEnforce ResponseValidation = "enforce"
+)
+
+// Configuration defines the configuration of the OpenAPI document and validation
+type Configuration struct {
+	Endpoint           string             `envconfig:"OPENAPI_ENDPOINT" default:"/openapi.json"`
+	ValidateRequests   bool               `envconfig:"OPENAPI_VALIDATE_REQUESTS" default:"true"`
+	ResponseValidation ResponseValidation `envconfig:"OPENAPI_RESPONSE_VALIDATION" default:"log"`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	switch c.ResponseValidation {
+	case ResponseValidationOff, ResponseValidationLog, ResponseValidationEnforce:
+		return nil
+	default:
+		return fmt.Errorf("invalid response validation '%s'", c.ResponseValidation)
+	}
+}
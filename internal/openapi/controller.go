@@ -0,0 +1,213 @@
+// Package openapi provides objects to describe the adapter API and validate requests against it
+package openapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// RESTController serves the OpenAPI document and validates the requests and responses against it
+type RESTController struct {
+	logger        logging.Logger
+	spec          *Spec
+	configuration Configuration
+}
+
+// NewRESTController initializes rest controller
+func NewRESTController(logger logging.Logger, spec *Spec, configuration Configuration) RESTController {
+	return RESTController{
+		logger:        logger,
+		spec:          spec,
+		configuration: configuration,
+	}
+}
+
+// Boot registers the document endpoint
+func (r RESTController) Boot(s server.Server) {
+	s.Router().GET(r.configuration.Endpoint, r.document())
+}
+
+func (r RESTController) document() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, r.spec.Document())
+	}
+}
+
+// Validate returns a middleware that validates the request against the operation of the route,
+// the routes that aren't described are ignored.
+// It must run after the authentication of the route, see auth.Guard.WithValidation.
+func (r RESTController) Validate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := r.route(ctx)
+		if route == nil {
+			ctx.Next()
+			return
+		}
+
+		pathParams := map[string]string{}
+		for _, param := range ctx.Params {
+			pathParams[param.Key] = param.Value
+		}
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    ctx.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+
+		if r.configuration.ValidateRequests {
+			if err := openapi3filter.ValidateRequest(ctx, requestInput); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+					Message: "request doesn't match the specification",
+					Errors:  sortedFieldErrors(err),
+				})
+				return
+			}
+		}
+
+		if r.configuration.ResponseValidation == ResponseValidationOff {
+			ctx.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = writer.ResponseWriter
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+			Options: &openapi3filter.Options{
+				MultiError:            true,
+				IncludeResponseStatus: true,
+			},
+		}
+
+		if err := openapi3filter.ValidateResponse(ctx, responseInput); err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("response of %s %s doesn't match the specification. Err: %v",
+				ctx.Request.Method, ctx.FullPath(), err)
+
+			if r.configuration.ResponseValidation == ResponseValidationEnforce {
+				ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+					Message: "response doesn't match the specification",
+					Errors:  sortedFieldErrors(err),
+				})
+				return
+			}
+		}
+
+		if writer.body.Len() == 0 {
+			writer.ResponseWriter.WriteHeaderNow()
+			return
+		}
+
+		if _, err := writer.ResponseWriter.Write(writer.body.Bytes()); err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("failed to write response with error %v", err)
+		}
+	}
+}
+
+func (r RESTController) route(ctx *gin.Context) *routers.Route {
+	path := ToOpenAPIPath(ctx.FullPath())
+	pathItem := r.spec.Document().Paths.Find(path)
+	if pathItem == nil {
+		return nil
+	}
+
+	operation := pathItem.GetOperation(ctx.Request.Method)
+	if operation == nil {
+		return nil
+	}
+
+	return &routers.Route{
+		Spec:      r.spec.Document(),
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    ctx.Request.Method,
+		Operation: operation,
+	}
+}
+
+// sortedFieldErrors returns the field errors sorted by field, so the responses are deterministic
+func sortedFieldErrors(err error) []FieldError {
+	result := fieldErrors(err)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Field < result[j].Field })
+	return result
+}
+
+// fieldErrors flattens the validation errors into the fields that don't match the schema,
+// body fields are identified by their JSON pointer and parameters by their name
+func fieldErrors(err error) []FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		result := []FieldError{}
+		for _, nested := range e {
+			result = append(result, fieldErrors(nested)...)
+		}
+		return result
+	case *openapi3.SchemaError:
+		return []FieldError{{Field: "/" + strings.Join(e.JSONPointer(), "/"), Reason: e.Reason}}
+	case *openapi3filter.RequestError:
+		prefix := ""
+		if e.Parameter != nil {
+			prefix = e.Parameter.Name
+		}
+		return prefixedFieldErrors(prefix, e.Reason, e.Err)
+	case *openapi3filter.ResponseError:
+		return prefixedFieldErrors("", e.Reason, e.Err)
+	default:
+		return []FieldError{{Reason: err.Error()}}
+	}
+}
+
+func prefixedFieldErrors(prefix string, reason string, err error) []FieldError {
+	if err == nil {
+		return []FieldError{{Field: prefix, Reason: reason}}
+	}
+
+	result := fieldErrors(err)
+	for i := range result {
+		if prefix == "" {
+			continue
+		}
+		if result[i].Field == "/" {
+			result[i].Field = prefix
+		} else {
+			result[i].Field = prefix + result[i].Field
+		}
+	}
+	return result
+}
+
+// bufferedWriter keeps the body in memory, so it can be validated before being written
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -0,0 +1,189 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+)
+
+type event struct {
+	DimensionID string    `json:"dimensionId"`
+	Quantity    float32   `json:"quantity"`
+	StartAt     time.Time `json:"startAt"`
+}
+
+type result struct {
+	Status string `json:"status"`
+}
+
+func TestValidation(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	spec := openapi.NewSpec("test", "v1")
+	spec.AddOperation(http.MethodPost, "/events/:kind", openapi.Operation{
+		ID:         "createEvent",
+		Parameters: openapi3.Parameters{{Value: openapi3.NewPathParameter("kind").WithSchema(openapi3.NewStringSchema())}},
+		Request:    spec.Schema(event{}, "dimensionId", "quantity", "startAt"),
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(result{}, "status")},
+	})
+
+	if err := spec.Validate(context.Background()); err != nil {
+		t.Fatalf("spec should be valid, got %v", err)
+	}
+
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	controller := openapi.NewRESTController(logger, spec, openapi.Configuration{
+		Endpoint:           "/openapi.json",
+		ValidateRequests:   true,
+		ResponseValidation: openapi.ResponseValidationEnforce,
+	})
+	controller.Boot(httpServer)
+
+	httpServer.Router().POST("/events/:kind", controller.Validate(), func(ctx *gin.Context) {
+		if ctx.Param("kind") == "broken" {
+			ctx.JSON(http.StatusOK, gin.H{"unexpected": true})
+			return
+		}
+		ctx.JSON(http.StatusOK, result{Status: "Accepted"})
+	})
+
+	tt := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		errors []openapi.FieldError
+	}{
+		{
+			name:   "valid request",
+			path:   "/events/gpu",
+			body:   `{"dimensionId":"gpu","quantity":1,"startAt":"2024-05-01T10:00:00Z"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "invalid request",
+			path:   "/events/gpu",
+			body:   `{"quantity":"one","startAt":"2024-05-01T10:00:00Z"}`,
+			status: http.StatusBadRequest,
+			errors: []openapi.FieldError{
+				{Field: "/dimensionId", Reason: `property "dimensionId" is missing`},
+				{Field: "/quantity", Reason: `value must be a number`},
+			},
+		},
+		{
+			name:   "invalid response",
+			path:   "/events/broken",
+			body:   `{"dimensionId":"gpu","quantity":1,"startAt":"2024-05-01T10:00:00Z"}`,
+			status: http.StatusInternalServerError,
+			errors: []openapi.FieldError{
+				{Field: "/status", Reason: `property "status" is missing`},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			httpServer.Router().ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.status {
+				t.Fatalf("should return %d, got %d: %s", tc.status, recorder.Code, recorder.Body.String())
+			}
+
+			if tc.errors == nil {
+				return
+			}
+
+			response := openapi.ErrorResponse{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("should return an error response, got %v", err)
+			}
+
+			if diff := cmp.Diff(tc.errors, response.Errors); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestValidationAfterAuthentication(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	spec := openapi.NewSpec("test", "v1")
+	spec.AddOperation(http.MethodPost, "/events/:kind", openapi.Operation{
+		ID:         "createEvent",
+		Parameters: openapi3.Parameters{{Value: openapi3.NewPathParameter("kind").WithSchema(openapi3.NewStringSchema())}},
+		Request:    spec.Schema(event{}, "dimensionId", "quantity", "startAt"),
+		Responses:  map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(result{}, "status")},
+	})
+
+	controller := openapi.NewRESTController(logger, spec, openapi.Configuration{ValidateRequests: true})
+	guard := auth.NewGuard(logger, []auth.Authenticator{
+		auth.NewBearerAuthenticator(map[string]string{"static-token": auth.ScopeMeteringWrite}),
+	}).WithValidation(controller.Validate())
+
+	httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+	httpServer.Router().POST("/events/:kind", guard.Require(auth.ScopeMeteringWrite), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, result{Status: "Accepted"})
+	})
+
+	tt := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{name: "invalid request without credentials", body: `{"quantity":"one"}`, status: http.StatusUnauthorized},
+		{name: "invalid request with credentials", token: "static-token", body: `{"quantity":"one"}`,
+			status: http.StatusBadRequest},
+		{name: "valid request with credentials", token: "static-token",
+			body: `{"dimensionId":"gpu","quantity":1,"startAt":"2024-05-01T10:00:00Z"}`, status: http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/events/gpu", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			httpServer.Router().ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.status {
+				t.Fatalf("should return %d, got %d: %s", tc.status, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
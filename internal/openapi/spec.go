@@ -0,0 +1,135 @@
+// Package openapi provides objects to describe the adapter API and validate requests against it
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+var ginParamRegex = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// ErrorResponse represents the body of the error responses
+type ErrorResponse struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError represents a value that doesn't match the schema
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Operation describes an endpoint of the API
+type Operation struct {
+	ID         string
+	Summary    string
+	Parameters openapi3.Parameters
+	// Request is the schema of the JSON body, when there is one
+	Request *openapi3.SchemaRef
+	// Responses maps the status codes to the schema of the JSON body
+	Responses map[int]*openapi3.SchemaRef
+}
+
+// Spec builds the OpenAPI 3 document of the adapter from the operations described by the controllers
+type Spec struct {
+	doc       *openapi3.T
+	generator *openapi3gen.Generator
+	// err is the first error generating a schema, it is returned by Validate
+	err error
+}
+
+// NewSpec initializes an empty specification
+func NewSpec(title string, version string) *Spec {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			Schemas: openapi3.Schemas{},
+		},
+	}
+
+	return &Spec{
+		doc:       doc,
+		generator: openapi3gen.NewGenerator(),
+	}
+}
+
+// Schema generates the schema of a value with the given required fields.
+// The schemas are shared by type, so the required fields also apply where the type is nested in other schemas,
+// and properties must be replaced instead of modified.
+// When the schema can't be generated, an empty object schema is returned and the error is reported by Validate.
+func (s *Spec) Schema(value any, required ...string) *openapi3.SchemaRef {
+	ref, err := s.generator.NewSchemaRefForValue(value, s.doc.Components.Schemas)
+	if err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("could not generate schema of %T. Err: %v", value, err)
+		}
+		return openapi3.NewObjectSchema().NewRef()
+	}
+
+	if len(required) > 0 {
+		ref.Value.Required = required
+	}
+
+	return ref
+}
+
+// AddOperation adds an operation to the document, the path follows the router syntax, e.g. /quota/:location
+func (s *Spec) AddOperation(method string, path string, operation Operation) {
+	op := openapi3.NewOperation()
+	op.OperationID = operation.ID
+	op.Summary = operation.Summary
+	op.Parameters = operation.Parameters
+
+	if operation.Request != nil {
+		op.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(operation.Request),
+		}
+	}
+
+	errorSchema := s.Schema(ErrorResponse{}, "message")
+	responses := openapi3.NewResponses()
+	responses.Delete("default")
+	for status, schema := range operation.Responses {
+		response := openapi3.NewResponse().WithDescription(http.StatusText(status))
+		if schema != nil {
+			response = response.WithJSONSchemaRef(schema)
+		}
+		responses.Set(strconv.Itoa(status), &openapi3.ResponseRef{Value: response})
+	}
+	responses.Set("default", &openapi3.ResponseRef{
+		Value: openapi3.NewResponse().WithDescription("Error").WithJSONSchemaRef(errorSchema),
+	})
+	op.Responses = responses
+
+	s.doc.AddOperation(ToOpenAPIPath(path), method, op)
+}
+
+// Document returns the OpenAPI document
+func (s *Spec) Document() *openapi3.T {
+	return s.doc
+}
+
+// Validate checks if the schemas were generated and the document is a valid OpenAPI 3 document
+func (s *Spec) Validate(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.doc.Validate(ctx)
+}
+
+// ToOpenAPIPath converts a router path into an OpenAPI path, e.g. /quota/:location into /quota/{location}
+func ToOpenAPIPath(path string) string {
+	return ginParamRegex.ReplaceAllString(path, "{$1}")
+}
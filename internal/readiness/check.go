@@ -0,0 +1,104 @@
+// Package readiness provides the readiness probe of the adapter, based on the checks of its dependencies
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	coreHTTP "github.com/ydataai/go-core/pkg/http"
+)
+
+// tokenRefreshMargin is how long before expiring a cached token is requested again
+const tokenRefreshMargin = 5 * time.Minute
+
+// Check defines a dependency that must be available for the adapter to be ready
+type Check interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type tokenCheck struct {
+	name       string
+	credential azcore.TokenCredential
+	scope      string
+
+	mu        sync.Mutex
+	expiresOn time.Time
+}
+
+// NewTokenCheck initializes a check that the credential gets a token for the scope, the token is cached until
+// it is about to expire
+func NewTokenCheck(name string, credential azcore.TokenCredential, scope string) Check {
+	return &tokenCheck{
+		name:       name,
+		credential: credential,
+		scope:      scope,
+	}
+}
+
+func (c *tokenCheck) Name() string {
+	return c.name
+}
+
+// Check requests a token, unless the previous one is still valid
+func (c *tokenCheck) Check(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Add(tokenRefreshMargin).Before(c.expiresOn) {
+		return nil
+	}
+
+	token, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{c.scope}})
+	if err != nil {
+		return fmt.Errorf("could not get a token for %s. Err: %v", c.scope, err)
+	}
+	c.expiresOn = token.ExpiresOn
+
+	return nil
+}
+
+type endpointCheck struct {
+	name string
+	url  string
+	pl   coreHTTP.Pipeline
+}
+
+// NewEndpointCheck initializes a check that the endpoint is reachable, any response below 500 means it is,
+// since the endpoint is requested without credentials
+func NewEndpointCheck(name string, url string) Check {
+	return endpointCheck{
+		name: name,
+		url:  url,
+		pl:   coreHTTP.NewPipeline(),
+	}
+}
+
+func (c endpointCheck) Name() string {
+	return c.name
+}
+
+// Check requests the endpoint
+func (c endpointCheck) Check(ctx context.Context) error {
+	req, err := coreHTTP.NewRequest(ctx, http.MethodGet, c.url)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.pl.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s is unreachable. Err: %v", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s responded with %s", c.url, resp.Status)
+	}
+
+	return nil
+}
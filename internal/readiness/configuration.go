@@ -0,0 +1,23 @@
+// Package readiness provides the readiness probe of the adapter, based on the checks of its dependencies
+package readiness
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Configuration represents the configuration of the readiness checks.
+type Configuration struct {
+	// Timeout of each check
+	Timeout time.Duration `envconfig:"READINESS_CHECK_TIMEOUT" default:"5s"`
+	// CacheTTL is how long the results are reused, so the probes don't request a token every time
+	CacheTTL time.Duration `envconfig:"READINESS_CACHE_TTL" default:"30s"`
+	// PingUpstreams enables the checks that the upstream APIs are reachable
+	PingUpstreams bool `envconfig:"READINESS_PING_UPSTREAMS" default:"false"`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
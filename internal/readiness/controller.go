@@ -0,0 +1,118 @@
+// Package readiness provides the readiness probe of the adapter, based on the checks of its dependencies
+package readiness
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+)
+
+// Check statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// CheckResult represents the result of a check
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report represents the readiness of the adapter, it is ready when every check is ok
+type Report struct {
+	Ready     bool                   `json:"ready"`
+	CheckedAt time.Time              `json:"checkedAt"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// RESTController defines the controller of the readiness probe
+type RESTController struct {
+	logger        logging.Logger
+	configuration Configuration
+	endpoint      string
+	checks        []Check
+
+	mu     *sync.Mutex
+	report *Report
+}
+
+// NewRESTController initializes the readiness probe served on the endpoint
+func NewRESTController(
+	logger logging.Logger, configuration Configuration, endpoint string, checks ...Check,
+) RESTController {
+	return RESTController{
+		logger:        logger,
+		configuration: configuration,
+		endpoint:      endpoint,
+		checks:        checks,
+		mu:            &sync.Mutex{},
+		report:        &Report{},
+	}
+}
+
+// Boot registers the readiness probe, replacing the one of server.Server.AddReadyz
+func (r RESTController) Boot(s server.Server) {
+	s.Router().GET(r.endpoint, r.readyz())
+}
+
+// Report returns the readiness of the adapter, the checks run again once the cached report expires
+func (r RESTController) Report(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.report.CheckedAt) < r.configuration.CacheTTL {
+		return *r.report
+	}
+
+	report := Report{
+		Ready:     true,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(r.checks)),
+	}
+
+	results := make([]error, len(r.checks))
+	wg := sync.WaitGroup{}
+	for i, check := range r.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+
+			tCtx, cancel := context.WithTimeout(ctx, r.configuration.Timeout)
+			defer cancel()
+
+			results[i] = check.Check(tCtx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range r.checks {
+		if err := results[i]; err != nil {
+			r.logger.Warnf("readiness check '%s' failed. Err: %v", check.Name(), err)
+			report.Ready = false
+			report.Checks[check.Name()] = CheckResult{Status: StatusFailed, Error: err.Error()}
+			continue
+		}
+		report.Checks[check.Name()] = CheckResult{Status: StatusOK}
+	}
+
+	*r.report = report
+	return report
+}
+
+func (r RESTController) readyz() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report := r.Report(ctx)
+		if !report.Ready {
+			ctx.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, report)
+	}
+}
@@ -0,0 +1,108 @@
+package readiness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/readiness"
+)
+
+type fakeCredential struct {
+	calls int
+	err   error
+}
+
+func (c *fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calls++
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestReadiness(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+	configuration := readiness.Configuration{Timeout: time.Second}
+
+	readyz := func(controller readiness.RESTController) (int, readiness.Report) {
+		gin.SetMode(gin.TestMode)
+		httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+		controller.Boot(httpServer)
+
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		report := readiness.Report{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		return recorder.Code, report
+	}
+
+	t.Run("caches the token", func(t *testing.T) {
+		credential := &fakeCredential{}
+		controller := readiness.NewRESTController(logger, configuration, "/readyz",
+			readiness.NewTokenCheck("marketplace-token", credential, "scope/.default"))
+
+		for i := 0; i < 2; i++ {
+			if status, _ := readyz(controller); status != http.StatusOK {
+				t.Fatalf("should be ready, got %d", status)
+			}
+		}
+
+		if credential.calls != 1 {
+			t.Fatalf("should request the token once, got %d", credential.calls)
+		}
+	})
+
+	t.Run("reports the failing checks", func(t *testing.T) {
+		controller := readiness.NewRESTController(logger, configuration, "/readyz",
+			readiness.NewTokenCheck("marketplace-token", &fakeCredential{err: errors.New("no identity")}, "scope/.default"),
+			readiness.NewTokenCheck("arm-token", &fakeCredential{}, "arm/.default"),
+		)
+
+		status, report := readyz(controller)
+		if status != http.StatusServiceUnavailable {
+			t.Fatalf("should not be ready, got %d", status)
+		}
+
+		expected := readiness.Report{
+			Ready: false,
+			Checks: map[string]readiness.CheckResult{
+				"marketplace-token": {Status: readiness.StatusFailed},
+				"arm-token":         {Status: readiness.StatusOK},
+			},
+		}
+		if diff := cmp.Diff(expected, report,
+			cmpopts.IgnoreFields(readiness.Report{}, "CheckedAt"),
+			cmpopts.IgnoreFields(readiness.CheckResult{}, "Error"),
+		); diff != "" {
+			t.Fatal(diff)
+		}
+
+		if report.Checks["marketplace-token"].Error == "" {
+			t.Fatal("failed check should have an error")
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Package rpc provides a gRPC server that runs alongside the HTTP server
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	coreGRPC "github.com/ydataai/go-core/pkg/common/grpc"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"google.golang.org/grpc"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+)
+
+// Server represents a gRPC Server where the controllers register their services
+type Server interface {
+	Registrar() grpc.ServiceRegistrar
+	Configuration() ServerConfiguration
+
+	Run(ctx context.Context) error
+	// Shutdown stops accepting requests and waits for the ones in-flight until the context is done,
+	// when they are cancelled
+	Shutdown(ctx context.Context) error
+}
+
+type server struct {
+	configuration ServerConfiguration
+	logger        logging.Logger
+	grpcServer    *grpc.Server
+}
+
+// NewServer initializes a gRPC server that logs every request and propagates its correlation ids,
+// the interceptors run after those, e.g. to authenticate the requests
+func NewServer(
+	logger logging.Logger, configuration ServerConfiguration, interceptors ...grpc.UnaryServerInterceptor,
+) Server {
+	chain := append([]grpc.UnaryServerInterceptor{
+		correlation.UnaryServerInterceptor(),
+		coreGRPC.LoggingUnaryServerInterceptor(logger),
+	}, interceptors...)
+
+	return &server{
+		configuration: configuration,
+		logger:        logger,
+		grpcServer:    grpc.NewServer(grpc.ChainUnaryInterceptor(chain...)),
+	}
+}
+
+func (s *server) Registrar() grpc.ServiceRegistrar {
+	return s.grpcServer
+}
+
+func (s *server) Configuration() ServerConfiguration {
+	return s.configuration
+}
+
+// Run starts listening and serves the requests in background, until the context is done
+func (s *server) Run(ctx context.Context) error {
+	address := fmt.Sprintf("%s:%d", s.configuration.Host, s.configuration.Port)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		s.logger.Infof("gRPC Server Running on [%s]", address)
+		if err := s.grpcServer.Serve(listener); err != nil {
+			s.logger.Errorf("unexpected error while running gRPC server %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		s.logger.Infof("Shutdown gRPC Server ...")
+		s.grpcServer.GracefulStop()
+	}()
+
+	return nil
+}
+
+// Shutdown stops accepting requests and waits for the ones in-flight until the context is done,
+// when they are cancelled
+func (s *server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,21 @@
+// Package rpc provides a gRPC server that runs alongside the HTTP server
+package rpc
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// ServerConfiguration defines the required configuration for the gRPC server
+type ServerConfiguration struct {
+	Enabled        bool          `envconfig:"GRPC_ENABLED" default:"false"`
+	Host           string        `envconfig:"GRPC_HOST" default:""`
+	Port           int           `envconfig:"GRPC_PORT" default:"9090"`
+	RequestTimeout time.Duration `envconfig:"GRPC_REQUEST_TIMEOUT" default:"30s"`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *ServerConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
@@ -0,0 +1,64 @@
+// Package setup builds the components shared by the commands of the adapter
+package setup
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/credential"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/tenant"
+	"github.com/ydataai/azure-adapter/internal/usage"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+)
+
+// NewCredential builds the credential of the process
+func NewCredential(c *Configuration) (azcore.TokenCredential, error) {
+	return credential.NewCredential(c.Credential)
+}
+
+// NewPlanHistory builds the history of the changes of plan, which the usage events are billed against
+func NewPlanHistory(c *Configuration) (metering.PlanHistory, error) {
+	return metering.NewPlanHistory(c.Metering.PlanHistoryPath)
+}
+
+// NewMeteringRegistry builds the registry that sends the usage of the default resource and of the tenants,
+// billing it against the plans of the history, skipping the usage of the installations that aren't billable
+// and alerting of the failures, when they aren't nil
+func NewMeteringRegistry(
+	logger logging.Logger,
+	c *Configuration,
+	cred azcore.TokenCredential,
+	installations metering.Installations,
+	plans metering.PlanResolver,
+	alerter metering.Alerter,
+) (tenant.Registry, error) {
+	sinks, err := metering.NewSinks(c.Sink)
+	if err != nil {
+		return nil, err
+	}
+
+	meteringConfiguration := c.Metering
+	meteringConfiguration.Plans = plans
+	meteringConfiguration.Alerter = alerter
+
+	return tenant.NewRegistry(logger, c.Tenant, meteringConfiguration, c.Sink, cred, sinks, installations)
+}
+
+// NewQuotaService builds the service that reads the compute quota of the subscription
+func NewQuotaService(
+	logger logging.Logger, c *Configuration, cred azcore.TokenCredential,
+) (usage.RESTService, error) {
+	computeUsageClient, err := compute.NewUsageClient(c.Application.SubscriptionID, cred, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{PerCallPolicies: []policy.Policy{correlation.NewPolicy()}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage.NewRESTService(logger, c.RESTService, usage.NewClient(computeUsageClient)), nil
+}
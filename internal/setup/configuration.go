@@ -0,0 +1,121 @@
+// Package setup builds the components shared by the commands of the adapter
+package setup
+
+import (
+	"fmt"
+
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/collector"
+	"github.com/ydataai/azure-adapter/internal/configuration"
+	"github.com/ydataai/azure-adapter/internal/credential"
+	"github.com/ydataai/azure-adapter/internal/fulfillment"
+	"github.com/ydataai/azure-adapter/internal/installation"
+	"github.com/ydataai/azure-adapter/internal/logs"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+	"github.com/ydataai/azure-adapter/internal/readiness"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+	"github.com/ydataai/azure-adapter/internal/shutdown"
+	"github.com/ydataai/azure-adapter/internal/tenant"
+	"github.com/ydataai/azure-adapter/internal/usage"
+)
+
+// Features defines the parts of the adapter a command runs, only their configurations are loaded
+type Features struct {
+	// Server runs the HTTP and gRPC servers
+	Server bool
+	// Metering sends the usage to the marketplace metering API
+	Metering bool
+	// Quota reads the compute quota of the subscription
+	Quota bool
+	// Fulfillment manages the lifecycle of the SaaS subscriptions
+	Fulfillment bool
+}
+
+// Configuration gathers the configurations of every part of the adapter
+type Configuration struct {
+	Features Features
+
+	File        configuration.File
+	Application configuration.Application
+	Credential  credential.Configuration
+	Logger      logging.LoggerConfiguration
+	Logs        logs.Configuration
+
+	HTTPServer     server.HTTPServerConfiguration
+	RESTController config.RESTControllerConfiguration
+	GRPCServer     rpc.ServerConfiguration
+	OpenAPI        openapi.Configuration
+	Auth           auth.Configuration
+	Readiness      readiness.Configuration
+	Shutdown       shutdown.Configuration
+
+	Metering     metering.Configuration
+	Sink         metering.SinkConfiguration
+	Alert        metering.AlertConfiguration
+	Prices       metering.PriceConfiguration
+	Collector    collector.Configuration
+	Tenant       tenant.Configuration
+	Installation installation.Configuration
+
+	RESTService usage.RESTServiceConfiguration
+
+	Fulfillment        fulfillment.Configuration
+	FulfillmentWebhook fulfillment.WebhookConfiguration
+}
+
+// Load reads the configurations of the features and initializes the logger
+func Load(features Features) (*Configuration, logging.Logger, error) {
+	c := &Configuration{Features: features}
+
+	if err := config.InitConfigurationVariables(c.variables()); err != nil {
+		return nil, nil, fmt.Errorf("could not set configuration variables. Err: %v", err)
+	}
+
+	if features.Metering && !c.Metering.HasResource() && len(c.Tenant.Tenants) == 0 {
+		return nil, nil, fmt.Errorf("either MANAGED_APP_RESOURCE_URI or METERING_TENANTS_FILE must be configured")
+	}
+
+	return c, logs.NewRedactingLogger(logging.NewLogger(c.Logger), c.Logs), nil
+}
+
+// variables returns the configurations of the features, the file goes first so it layers under the env vars
+func (c *Configuration) variables() []config.ConfigurationVariables {
+	variables := []config.ConfigurationVariables{
+		&c.File,
+		&c.Application,
+		&c.Credential,
+		&c.Logger,
+		&c.Logs,
+	}
+
+	if c.Features.Server {
+		variables = append(variables,
+			&c.HTTPServer,
+			&c.RESTController,
+			&c.GRPCServer,
+			&c.OpenAPI,
+			&c.Auth,
+			&c.Readiness,
+			&c.Shutdown,
+		)
+	}
+
+	if c.Features.Metering {
+		variables = append(variables, &c.Metering, &c.Sink, &c.Alert, &c.Prices, &c.Collector, &c.Tenant, &c.Installation)
+	}
+
+	if c.Features.Quota {
+		variables = append(variables, &c.RESTService)
+	}
+
+	if c.Features.Fulfillment {
+		variables = append(variables, &c.Fulfillment, &c.FulfillmentWebhook)
+	}
+
+	return variables
+}
@@ -0,0 +1,49 @@
+package setup_test
+
+import (
+	"testing"
+
+	"github.com/ydataai/azure-adapter/internal/setup"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("SUBSCRIPTION_ID", "subscription")
+
+	t.Run("loads only the configurations of the features", func(t *testing.T) {
+		configuration, _, err := setup.Load(setup.Features{Server: true})
+		if err != nil {
+			t.Fatalf("expected the quota configuration to be skipped, got %v", err)
+		}
+		if configuration.Shutdown.Timeout == 0 {
+			t.Fatal("expected the server configurations to be loaded")
+		}
+	})
+
+	t.Run("requires the configuration of the quota", func(t *testing.T) {
+		if _, _, err := setup.Load(setup.Features{Quota: true}); err == nil {
+			t.Fatal("expected LOCATION and MACHINE_TYPE to be required")
+		}
+
+		t.Setenv("LOCATION", "westeurope")
+		t.Setenv("MACHINE_TYPE", "standardNCSv3Family")
+		configuration, _, err := setup.Load(setup.Features{Quota: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if configuration.RESTService.Location != "westeurope" {
+			t.Fatalf("expected the location to be loaded, got %q", configuration.RESTService.Location)
+		}
+	})
+
+	t.Run("requires a resource or tenants to meter", func(t *testing.T) {
+		if _, _, err := setup.Load(setup.Features{Metering: true}); err == nil {
+			t.Fatal("expected an error without a resource nor tenants")
+		}
+
+		t.Setenv("MANAGED_APP_RESOURCE_URI", "/subscriptions/subscription/resourceGroups/group")
+		t.Setenv("MANAGED_APP_PLAN_ID", "plan")
+		if _, _, err := setup.Load(setup.Features{Metering: true}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+// Package setup builds the components shared by the commands of the adapter
+package setup
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/collector"
+	"github.com/ydataai/azure-adapter/internal/configuration"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/fulfillment"
+	"github.com/ydataai/azure-adapter/internal/installation"
+	"github.com/ydataai/azure-adapter/internal/logs"
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/openapi"
+	"github.com/ydataai/azure-adapter/internal/readiness"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+	"github.com/ydataai/azure-adapter/internal/shutdown"
+	"github.com/ydataai/azure-adapter/internal/tenant"
+	"github.com/ydataai/azure-adapter/internal/usage"
+)
+
+// controller is implemented by the controllers of the features
+type controller interface {
+	Boot(s server.Server)
+	Describe(spec *openapi.Spec)
+}
+
+// grpcController is implemented by the gRPC controllers of the features
+type grpcController interface {
+	Boot(s rpc.Server)
+	// Scopes returns the scopes each full method of the controller requires
+	Scopes() map[string][]string
+}
+
+// Serve runs the controllers of the features in one server until the process is signaled,
+// and returns the exit code of the shutdown
+func Serve(ctx context.Context, logger logging.Logger, c *Configuration) int {
+	cred, err := NewCredential(c)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// the controllers describe their operations once they are initialized, the requests are validated
+	// against the specification after the guards authenticate them
+	spec := openapi.NewSpec(specTitle(c.Features), "v1")
+	openapiController := openapi.NewRESTController(logger, spec, c.OpenAPI)
+	guard := auth.NewGuard(logger, auth.NewAuthenticators(c.Auth)).WithValidation(openapiController.Validate())
+	coordinator := shutdown.NewCoordinator(logger, c.Shutdown)
+	watcher := configuration.NewWatcher(logger, &c.File, c.Tenant.File)
+
+	controllers := []controller{}
+	grpcControllers := []grpcController{}
+	checks := []readiness.Check{}
+
+	var meteringClient tenant.Registry
+	var alerts metering.AlertNotifier
+	var plans metering.PlanHistory
+	var installations installation.Registry
+	var operationStore fulfillment.OperationStore
+	if c.Features.Metering {
+		plans, err = NewPlanHistory(c)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		if c.Installation.Enabled {
+			installations, err = installation.NewRegistry(c.Installation.Path, plans)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			controllers = append(controllers,
+				installation.NewRESTController(logger, installations, guard, c.Installation))
+		}
+
+		alerts = metering.NewAlertNotifier(c.Alert, logger)
+		meteringClient, err = NewMeteringRegistry(logger, c, cred, installations, plans, alerts)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		for tenantID, tenantCredential := range meteringClient.Credentials() {
+			name := "marketplace-token"
+			if tenantID != "" {
+				name += "/" + tenantID
+			}
+			checks = append(checks, readiness.NewTokenCheck(name, tenantCredential, metering.TokenScope))
+		}
+		if c.Readiness.PingUpstreams {
+			checks = append(checks, readiness.NewEndpointCheck("marketplace", metering.Endpoint))
+		}
+
+		controllers = append(controllers,
+			metering.NewRESTController(logger, meteringClient, guard, c.RESTController),
+			metering.NewReportController(logger, meteringClient, c.Prices.Table, guard, c.RESTController),
+		)
+		grpcControllers = append(grpcControllers, metering.NewGRPCController(logger, meteringClient, c.GRPCServer))
+
+		watcher.OnReload(func() (func(), error) {
+			meteringConfiguration := metering.Configuration{}
+			tenantConfiguration := tenant.Configuration{}
+			if err := config.InitConfigurationVariables([]config.ConfigurationVariables{
+				&meteringConfiguration,
+				&tenantConfiguration,
+			}); err != nil {
+				return nil, err
+			}
+			return func() { meteringClient.Reload(meteringConfiguration, tenantConfiguration) }, nil
+		})
+	}
+
+	if c.Features.Quota {
+		restService, err := NewQuotaService(logger, c, cred)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		checks = append(checks, readiness.NewTokenCheck("arm-token", cred, usage.TokenScope))
+		if c.Readiness.PingUpstreams {
+			checks = append(checks, readiness.NewEndpointCheck("arm", usage.Endpoint))
+		}
+
+		controllers = append(controllers, usage.NewRESTController(logger, restService, guard, c.RESTController))
+		grpcControllers = append(grpcControllers, usage.NewGRPCController(logger, restService, c.GRPCServer))
+
+		watcher.OnReload(func() (func(), error) {
+			restServiceConfiguration := usage.RESTServiceConfiguration{}
+			if err := restServiceConfiguration.LoadFromEnvVars(); err != nil {
+				return nil, err
+			}
+			return func() { restService.Reload(restServiceConfiguration) }, nil
+		})
+	}
+
+	if c.Features.Fulfillment {
+		fulfillmentClient, err := fulfillment.NewClient(cred, c.Fulfillment, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		checks = append(checks, readiness.NewTokenCheck("fulfillment-token", cred, fulfillment.TokenScope))
+		controllers = append(controllers,
+			fulfillment.NewRESTController(logger, fulfillmentClient, guard, c.RESTController))
+
+		if c.FulfillmentWebhook.Enabled() {
+			operationStore, err = fulfillment.NewOperationStore(c.FulfillmentWebhook.OperationsPath)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			// the changes of plan are recorded when the usage of the subscriptions is metered too
+			webhookGuard := auth.NewGuard(logger, auth.NewAuthenticators(c.FulfillmentWebhook.Authentication())).
+				WithValidation(openapiController.Validate())
+			controllers = append(controllers, fulfillment.NewWebhookController(logger, fulfillmentClient, operationStore,
+				fulfillment.NewCallback(c.FulfillmentWebhook), plans, webhookGuard, c.RESTController))
+		}
+	}
+
+	readinessController := readiness.NewRESTController(logger, c.Readiness, c.HTTPServer.ReadyzEndpoint, checks...)
+
+	for _, controller := range controllers {
+		controller.Describe(spec)
+	}
+	if err := spec.Validate(ctx); err != nil {
+		logger.Fatal(err)
+	}
+
+	tracker := shutdown.NewTracker()
+
+	// the access logs of gin have neither the request ids nor the logger format, the correlation access logs replace them.
+	// The errors dump the requests, which may have secrets like the sig of the notifications
+	gin.DefaultWriter = io.Discard
+	gin.DefaultErrorWriter = logs.NewRedactingWriter(gin.DefaultErrorWriter, c.Logs)
+	httpServer := server.NewServer(logger, c.HTTPServer)
+	httpServer.AddHealthz()
+	readinessController.Boot(httpServer)
+	httpServer.Router().Use(correlation.Middleware(), correlation.AccessLog(logger), tracker.Middleware())
+	openapiController.Boot(httpServer)
+	for _, controller := range controllers {
+		controller.Boot(httpServer)
+	}
+	httpServer.Run(ctx)
+	coordinator.Add("http", tracker.Drain)
+
+	if c.GRPCServer.Enabled {
+		scopes := map[string][]string{}
+		for _, controller := range grpcControllers {
+			for method, methodScopes := range controller.Scopes() {
+				scopes[method] = methodScopes
+			}
+		}
+
+		grpcServer := rpc.NewServer(logger, c.GRPCServer, guard.UnaryServerInterceptor(scopes))
+		for _, controller := range grpcControllers {
+			controller.Boot(grpcServer)
+		}
+		if err := grpcServer.Run(ctx); err != nil {
+			logger.Fatal(err)
+		}
+		coordinator.Add("grpc", grpcServer.Shutdown)
+	}
+
+	go watcher.Run(ctx)
+
+	// the metering client closes after the servers and the collector drain the events that use it
+	if meteringClient != nil {
+		if len(c.Collector.Endpoints) > 0 {
+			collectorCtx, stopCollector := context.WithCancel(ctx)
+			usageCollector, err := collector.NewCollector(logger, c.Collector, meteringClient)
+			if err != nil {
+				stopCollector()
+				logger.Fatal(err)
+			}
+			go usageCollector.Run(collectorCtx)
+			coordinator.Add("collector", func(ctx context.Context) error {
+				stopCollector()
+				return usageCollector.Shutdown(ctx)
+			})
+		}
+		coordinator.Add("metering", meteringClient.Close)
+	}
+
+	if alerts != nil {
+		coordinator.Add("alerts", alerts.Close)
+	}
+
+	if installations != nil {
+		coordinator.Add("installations", func(context.Context) error {
+			return installations.Close()
+		})
+	}
+
+	if plans != nil {
+		coordinator.Add("plans", func(context.Context) error {
+			return plans.Close()
+		})
+	}
+
+	if operationStore != nil {
+		coordinator.Add("fulfillment-operations", func(context.Context) error {
+			return operationStore.Close()
+		})
+	}
+
+	return coordinator.Wait(ctx)
+}
+
+func specTitle(features Features) string {
+	switch features {
+	case Features{Server: true, Metering: true}:
+		return "Azure Adapter Metering API"
+	case Features{Server: true, Quota: true}:
+		return "Azure Adapter Quota API"
+	case Features{Server: true, Fulfillment: true}:
+		return "Azure Adapter Fulfillment API"
+	default:
+		return "Azure Adapter API"
+	}
+}
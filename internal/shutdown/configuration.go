@@ -0,0 +1,19 @@
+// Package shutdown provides the graceful shutdown of the adapter on termination signals
+package shutdown
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Configuration represents the configuration of the graceful shutdown.
+type Configuration struct {
+	// Timeout bounds the whole shutdown, it should be lower than the termination grace period of the pod
+	Timeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"25s"`
+}
+
+// LoadFromEnvVars parses the required configuration variables
+func (c *Configuration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
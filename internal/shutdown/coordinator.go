@@ -0,0 +1,87 @@
+// Package shutdown provides the graceful shutdown of the adapter on termination signals
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// Exit codes of the adapter
+const (
+	// ExitOK means every step of the shutdown completed
+	ExitOK = 0
+	// ExitTimeout means the shutdown timed out, e.g. requests or usage events were still in-flight
+	ExitTimeout = 2
+	// ExitFailure means a step of the shutdown failed
+	ExitFailure = 3
+)
+
+type step struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Coordinator runs the steps of the shutdown in the order they were added, once the process is signaled
+type Coordinator struct {
+	logger        logging.Logger
+	configuration Configuration
+	steps         []step
+}
+
+// NewCoordinator initializes a coordinator without steps
+func NewCoordinator(logger logging.Logger, configuration Configuration) *Coordinator {
+	return &Coordinator{
+		logger:        logger,
+		configuration: configuration,
+	}
+}
+
+// Add appends a step to the shutdown, e.g. draining the requests before closing the clients they use
+func (c *Coordinator) Add(name string, run func(ctx context.Context) error) {
+	c.steps = append(c.steps, step{name: name, run: run})
+}
+
+// Wait blocks until SIGINT or SIGTERM, then runs the steps within the timeout and returns the exit code
+func (c *Coordinator) Wait(ctx context.Context) int {
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	<-signalCtx.Done()
+	c.logger.Infof("shutting down within %v", c.configuration.Timeout)
+
+	return c.Shutdown(ctx)
+}
+
+// Shutdown runs the steps within the timeout and returns the exit code
+func (c *Coordinator) Shutdown(ctx context.Context) int {
+	tCtx, cancel := context.WithTimeout(ctx, c.configuration.Timeout)
+	defer cancel()
+
+	code := ExitOK
+	for _, step := range c.steps {
+		start := time.Now()
+
+		err := step.run(tCtx)
+		switch {
+		case err == nil:
+			c.logger.Infof("shutdown of %s completed in %v", step.name, time.Since(start))
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+			c.logger.Errorf("shutdown of %s timed out", step.name)
+			code = ExitTimeout
+		default:
+			c.logger.Errorf("shutdown of %s failed. Err: %v", step.name, err)
+			if code == ExitOK {
+				code = ExitFailure
+			}
+		}
+	}
+
+	c.logger.Infof("shutdown completed with exit code %d", code)
+	return code
+}
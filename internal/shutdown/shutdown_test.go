@@ -0,0 +1,128 @@
+package shutdown_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/shutdown"
+)
+
+func TestTracker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tracker := shutdown.NewTracker()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(tracker.Middleware())
+	router.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	inFlight := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		router.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		close(served)
+	}()
+	<-started
+
+	t.Run("waits for the requests in-flight", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := tracker.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the drain to time out, got %v", err)
+		}
+	})
+
+	t.Run("rejects new requests", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+		}
+		if recorder.Header().Get("Connection") != "close" {
+			t.Fatalf("expected the connection to be closed, got %q", recorder.Header().Get("Connection"))
+		}
+	})
+
+	t.Run("completes once the requests are served", func(t *testing.T) {
+		close(release)
+		<-served
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := tracker.Drain(ctx); err != nil {
+			t.Fatalf("expected the drain to complete, got %v", err)
+		}
+		if inFlight.Code != http.StatusOK {
+			t.Fatalf("expected the request in-flight to be served, got %d", inFlight.Code)
+		}
+	})
+}
+
+func TestCoordinator(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+	configuration := shutdown.Configuration{Timeout: 50 * time.Millisecond}
+
+	ok := func(context.Context) error { return nil }
+	failed := func(context.Context) error { return errors.New("failed") }
+	blocked := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	for _, tt := range []struct {
+		name  string
+		steps []func(context.Context) error
+		code  int
+	}{
+		{name: "completed", steps: []func(context.Context) error{ok, ok}, code: shutdown.ExitOK},
+		{name: "failed", steps: []func(context.Context) error{failed, ok}, code: shutdown.ExitFailure},
+		{name: "timed out", steps: []func(context.Context) error{blocked, ok}, code: shutdown.ExitTimeout},
+		{name: "timed out after failing", steps: []func(context.Context) error{failed, blocked}, code: shutdown.ExitTimeout},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			order := []int{}
+			coordinator := shutdown.NewCoordinator(logger, configuration)
+			for i, step := range tt.steps {
+				i, step := i, step
+				coordinator.Add(fmt.Sprintf("step %d", i), func(ctx context.Context) error {
+					order = append(order, i)
+					return step(ctx)
+				})
+			}
+
+			if code := coordinator.Shutdown(context.Background()); code != tt.code {
+				t.Fatalf("expected exit code %d, got %d", tt.code, code)
+			}
+			if len(order) != len(tt.steps) || order[0] != 0 {
+				t.Fatalf("expected every step to run in order, got %v", order)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package shutdown provides the graceful shutdown of the adapter on termination signals
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errDraining = errors.New("the adapter is shutting down")
+
+// Tracker counts the requests in-flight, so the shutdown waits for them before closing the clients they use
+type Tracker struct {
+	mu       sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{}
+}
+
+// NewTracker initializes a tracker without requests in-flight
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Middleware tracks the requests of the routes registered after it, rejecting them once draining
+func (t *Tracker) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !t.start() {
+			ctx.Header("Connection", "close")
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"message": errDraining.Error()})
+			return
+		}
+		defer t.done()
+
+		ctx.Next()
+	}
+}
+
+// Drain rejects new requests and waits for the ones in-flight until the context is done
+func (t *Tracker) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	idle := make(chan struct{})
+	if t.active == 0 {
+		close(idle)
+	}
+	t.idle = idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracker) start() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return false
+	}
+	t.active++
+	return true
+}
+
+func (t *Tracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active--
+	if t.active == 0 && t.draining {
+		close(t.idle)
+	}
+}
@@ -0,0 +1,127 @@
+// Package tenant provides a registry to meter the usage of multiple installations in one process
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/ydataai/azure-adapter/internal/credential"
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Configuration represents the configuration of the tenant registry.
+type Configuration struct {
+	File string `envconfig:"METERING_TENANTS_FILE" default:""`
+	// LedgerDir is the directory of the ledger files of the tenants, named after the tenant id.
+	// The ledgers are kept in memory only when it is empty.
+	LedgerDir string `envconfig:"METERING_TENANTS_LEDGER_DIR" default:""`
+
+	Tenants []Tenant `ignored:"true"`
+}
+
+// Tenant represents an installation billed by the adapter
+type Tenant struct {
+	ID          string             `json:"id"`
+	ResourceURI string             `json:"resourceUri"`
+	PlanID      string             `json:"planId"`
+	OfferType   metering.OfferType `json:"offerType"`
+	// Credential sends the usage of the tenant, the tenants without a credential type use the one of the process
+	Credential credential.Configuration `json:"credential"`
+	RateLimit  RateLimitConfiguration   `json:"rateLimit"`
+	// DimensionCaps replaces the monthly caps of the metering configuration for the tenant, when set
+	DimensionCaps map[string]float64 `json:"dimensionCaps,omitempty"`
+}
+
+// metering returns the metering configuration of the tenant, which bills its resource against its plan
+func (t Tenant) metering(meteringConfiguration metering.Configuration) metering.Configuration {
+	meteringConfiguration.ResourceUri = t.ResourceURI
+	meteringConfiguration.PlanId = t.PlanID
+	meteringConfiguration.OfferType = t.OfferType
+	if t.DimensionCaps != nil {
+		meteringConfiguration.DimensionCaps = t.DimensionCaps
+	}
+	return meteringConfiguration
+}
+
+// RateLimitConfiguration limits the requests of a tenant, there is no limit when the rate is zero
+type RateLimitConfiguration struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+// LoadFromEnvVars reads all env vars required for the registry and the tenants from the configured file.
+func (c *Configuration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+
+	if c.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+
+	file := struct {
+		Tenants []Tenant `json:"tenants"`
+	}{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("could not parse tenants file %s. Err: %v", c.File, err)
+	}
+	c.Tenants = file.Tenants
+
+	return c.validate()
+}
+
+func (c *Configuration) validate() error {
+	ids := map[string]bool{}
+
+	for i := range c.Tenants {
+		tenant := &c.Tenants[i]
+
+		if !tenantIDPattern.MatchString(tenant.ID) {
+			return fmt.Errorf("invalid tenant id '%s'", tenant.ID)
+		}
+		if ids[tenant.ID] {
+			return fmt.Errorf("tenant '%s' is duplicated", tenant.ID)
+		}
+		ids[tenant.ID] = true
+
+		if tenant.ResourceURI == "" || tenant.PlanID == "" {
+			return fmt.Errorf("tenant '%s' requires a resourceUri and a planId", tenant.ID)
+		}
+
+		if tenant.OfferType == "" {
+			tenant.OfferType = metering.OfferTypeManagedApplication
+		}
+		if tenant.OfferType != metering.OfferTypeManagedApplication && tenant.OfferType != metering.OfferTypeSaaS {
+			return fmt.Errorf("tenant '%s' has an invalid offer type '%s'", tenant.ID, tenant.OfferType)
+		}
+
+		for dimension, limit := range tenant.DimensionCaps {
+			if limit < 0 {
+				return fmt.Errorf("tenant '%s' cap of dimension '%s' must not be negative", tenant.ID, dimension)
+			}
+		}
+
+		if tenant.Credential.Type != "" {
+			if err := tenant.Credential.Validate(); err != nil {
+				return fmt.Errorf("tenant '%s' %v", tenant.ID, err)
+			}
+		}
+
+		if tenant.RateLimit.RequestsPerSecond < 0 || tenant.RateLimit.Burst < 0 {
+			return fmt.Errorf("tenant '%s' rate limit must not be negative", tenant.ID)
+		}
+	}
+
+	return nil
+}
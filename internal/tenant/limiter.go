@@ -0,0 +1,103 @@
+// Package tenant provides a registry to meter the usage of multiple installations in one process
+package tenant
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// limiter is a token bucket that refills at a constant rate up to the burst
+type limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(config RateLimitConfiguration) *limiter {
+	burst := float64(config.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		rate:   config.RequestsPerSecond,
+		burst:  burst,
+		now:    time.Now,
+		tokens: burst,
+	}
+}
+
+// update applies a new rate and burst, keeping the tokens available up to the new burst
+func (l *limiter) update(config RateLimitConfiguration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = config.RequestsPerSecond
+	l.burst = float64(config.Burst)
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// wait takes a token, waiting for it when the bucket is empty. It fails immediately with ErrRateLimited when
+// the token isn't available before the context deadline.
+func (l *limiter) wait(ctx context.Context) error {
+	delay := l.reserve(ctx)
+	if delay < 0 {
+		return metering.ErrRateLimited
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long to wait for it, or a negative duration when it can't be taken
+func (l *limiter) reserve(ctx context.Context) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	delay := time.Duration(0)
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		return -1
+	}
+
+	l.tokens--
+	return delay
+}
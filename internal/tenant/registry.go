@@ -0,0 +1,282 @@
+// Package tenant provides a registry to meter the usage of multiple installations in one process
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/credential"
+	"github.com/ydataai/azure-adapter/internal/metering"
+)
+
+// Registry defines a metering client that routes the usage events to the client of their tenant,
+// given by metering.TenantFromContext, where the empty tenant is the one of the metering configuration
+type Registry interface {
+	metering.FanOutClient
+	metering.Accounts
+	// Credentials returns the credential of each tenant
+	Credentials() map[string]azcore.TokenCredential
+	// Reload applies the safe fields of the metering configuration to every tenant and their new rate limits
+	Reload(meteringConfiguration metering.Configuration, configuration Configuration)
+}
+
+type tenantClient struct {
+	client     metering.FanOutClient
+	credential azcore.TokenCredential
+	limiter    *limiter
+	ledger     metering.Ledger
+	// plan resolves the plan the tenant is billed for, which may change over time
+	plan metering.Configuration
+}
+
+type registry struct {
+	logger        logging.Logger
+	tenants       map[string]tenantClient
+	sinks         []metering.Sink
+	installations metering.Installations
+}
+
+// NewRegistry initializes a client for the tenant of the metering configuration, when it has a resource,
+// and for each tenant of the configuration. Every client has its own rate limit and ledger, and shares the sinks.
+// When the installations aren't nil, the usage of the installations that aren't billable is skipped.
+// The usage of the dimensions with a monthly cap is kept below it, counting the events accepted in the ledger.
+// When the metering configuration has an alerter, it receives the failures and rejections of the usage events.
+func NewRegistry(
+	logger logging.Logger,
+	configuration Configuration,
+	meteringConfiguration metering.Configuration,
+	sinkConfiguration metering.SinkConfiguration,
+	credential azcore.TokenCredential,
+	sinks []metering.Sink,
+	installations metering.Installations,
+) (Registry, error) {
+	r := &registry{
+		logger:        logger,
+		tenants:       map[string]tenantClient{},
+		sinks:         sinks,
+		installations: installations,
+	}
+
+	if meteringConfiguration.HasResource() {
+		if err := r.add("", meteringConfiguration, credential, RateLimitConfiguration{},
+			sinkConfiguration.LedgerPath, sinkConfiguration); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tenant := range configuration.Tenants {
+		tenantConfiguration := tenant.metering(meteringConfiguration)
+
+		tenantCredential, err := newCredential(tenant.Credential, credential)
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize credential of tenant '%s'. Err: %v", tenant.ID, err)
+		}
+
+		ledgerPath := ""
+		if configuration.LedgerDir != "" {
+			ledgerPath = filepath.Join(configuration.LedgerDir, tenant.ID+".ndjson")
+		}
+
+		if err := r.add(tenant.ID, tenantConfiguration, tenantCredential, tenant.RateLimit,
+			ledgerPath, sinkConfiguration); err != nil {
+			return nil, err
+		}
+		logger.Infof("registered tenant '%s' for %s", tenant.ID, tenant.ResourceURI)
+	}
+
+	return r, nil
+}
+
+func (r *registry) add(
+	tenantID string,
+	meteringConfiguration metering.Configuration,
+	credential azcore.TokenCredential,
+	rateLimit RateLimitConfiguration,
+	ledgerPath string,
+	sinkConfiguration metering.SinkConfiguration,
+) error {
+	client, err := metering.NewClient(credential, meteringConfiguration, r.logger)
+	if err != nil {
+		return err
+	}
+	if r.installations != nil {
+		client = metering.NewInstallationClient(client, meteringConfiguration.ResourceUri, r.installations, r.logger)
+	}
+	// the breaker is reloadable, so it is disabled instead of left out when there are no consecutive failures
+	client = metering.NewBreakerClient(client, meteringConfiguration, r.logger)
+	if meteringConfiguration.Alerter != nil {
+		client = metering.NewMonitorClient(client, meteringConfiguration, r.logger)
+	}
+
+	ledger, err := metering.NewLedger(ledgerPath, sinkConfiguration.LedgerRetention)
+	if err != nil {
+		return err
+	}
+
+	sinks := []metering.Sink{ledger}
+	for _, sink := range r.sinks {
+		sinks = append(sinks, sharedSink{sink})
+	}
+
+	// the caps are reloadable, so the clients of the tenants without caps keep below the ones configured later
+	fanOut := metering.NewCapClient(
+		metering.NewFanOutClient(client, sinks, sinkConfiguration, r.logger), meteringConfiguration, ledger, r.logger)
+
+	r.tenants[tenantID] = tenantClient{
+		client:     fanOut,
+		credential: credential,
+		limiter:    newLimiter(rateLimit),
+		ledger:     ledger,
+		plan:       meteringConfiguration,
+	}
+
+	return nil
+}
+
+// CreateUsageEvent sends the event with the client of the tenant
+func (r *registry) CreateUsageEvent(
+	ctx context.Context, event coreMetering.UsageEvent,
+) (metering.UsageEventResponse, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return metering.UsageEventResponse{}, err
+	}
+
+	return tenant.client.CreateUsageEvent(ctx, event)
+}
+
+// BatchCreateUsageEvent sends the batch with the client of the tenant
+func (r *registry) BatchCreateUsageEvent(
+	ctx context.Context, batch coreMetering.UsageEventBatch,
+) (*metering.UsageEventBatchResponse, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant.client.BatchCreateUsageEvent(ctx, batch)
+}
+
+// Ledger returns the ledger of the tenant
+func (r *registry) Ledger(tenantID string) (metering.Ledger, error) {
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", metering.ErrUnknownTenant, tenantID)
+	}
+	return tenant.ledger, nil
+}
+
+// PlanAt returns the plan the usage of the tenant that started at the time is billed against
+func (r *registry) PlanAt(tenantID string, at time.Time) (string, error) {
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return "", fmt.Errorf("%w '%s'", metering.ErrUnknownTenant, tenantID)
+	}
+	return tenant.plan.PlanAt(at), nil
+}
+
+// Credentials returns the credential of each tenant
+func (r *registry) Credentials() map[string]azcore.TokenCredential {
+	credentials := make(map[string]azcore.TokenCredential, len(r.tenants))
+	for tenantID, tenant := range r.tenants {
+		credentials[tenantID] = tenant.credential
+	}
+	return credentials
+}
+
+// Reload applies the safe fields of the metering configuration to every tenant, with their own caps,
+// and their new rate limits. The tenants that were added or removed require a restart.
+func (r *registry) Reload(meteringConfiguration metering.Configuration, configuration Configuration) {
+	if client, ok := r.tenants[""]; ok {
+		reload(client, meteringConfiguration)
+	}
+
+	configured := map[string]bool{"": true}
+	for _, tenant := range configuration.Tenants {
+		configured[tenant.ID] = true
+
+		client, ok := r.tenants[tenant.ID]
+		if !ok {
+			r.logger.Warnf("tenant '%s' was added, it requires a restart", tenant.ID)
+			continue
+		}
+		reload(client, tenant.metering(meteringConfiguration))
+		client.limiter.update(tenant.RateLimit)
+	}
+
+	for tenantID := range r.tenants {
+		if !configured[tenantID] {
+			r.logger.Warnf("tenant '%s' was removed, it requires a restart", tenantID)
+		}
+	}
+}
+
+// Close drains the clients of every tenant and then closes the shared sinks
+func (r *registry) Close(ctx context.Context) error {
+	var err error
+	for tenantID, tenant := range r.tenants {
+		if closeErr := tenant.client.Close(ctx); closeErr != nil {
+			r.logger.Errorf("failed to close client of tenant '%s'. Err: %v", tenantID, closeErr)
+			err = closeErr
+		}
+	}
+
+	for _, sink := range r.sinks {
+		if closeErr := sink.Close(); closeErr != nil {
+			r.logger.Errorf("failed to close sink '%s'. Err: %v", sink.Name(), closeErr)
+		}
+	}
+
+	return err
+}
+
+// tenant returns the client of the tenant, once the rate limit allows it
+func (r *registry) tenant(ctx context.Context) (tenantClient, error) {
+	tenantID := metering.TenantFromContext(ctx)
+
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		if tenantID == "" {
+			return tenantClient{}, fmt.Errorf("%w, the request has no tenant", metering.ErrUnknownTenant)
+		}
+		return tenantClient{}, fmt.Errorf("%w '%s'", metering.ErrUnknownTenant, tenantID)
+	}
+
+	if err := tenant.limiter.wait(ctx); err != nil {
+		correlation.Logger(ctx, r.logger).Warnf("tenant '%s' request was throttled. Err: %v", tenantID, err)
+		return tenantClient{}, fmt.Errorf("tenant '%s': %w", tenantID, err)
+	}
+
+	return tenant, nil
+}
+
+// sharedSink is a sink of multiple tenants, closed by the registry once every tenant is closed
+type sharedSink struct {
+	metering.Sink
+}
+
+func (s sharedSink) Close() error {
+	return nil
+}
+
+func newCredential(
+	configuration credential.Configuration, fallback azcore.TokenCredential,
+) (azcore.TokenCredential, error) {
+	if configuration.Type == "" {
+		return fallback, nil
+	}
+	return credential.NewCredential(configuration)
+}
+
+func reload(tenant tenantClient, meteringConfiguration metering.Configuration) {
+	if reloadable, ok := tenant.client.(metering.Reloadable); ok {
+		reloadable.Reload(meteringConfiguration)
+	}
+}
@@ -0,0 +1,100 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	coreMetering "github.com/ydataai/go-core/pkg/metering"
+
+	"github.com/ydataai/azure-adapter/internal/metering"
+	"github.com/ydataai/azure-adapter/internal/tenant"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	configuration := tenant.Configuration{
+		Tenants: []tenant.Tenant{
+			{
+				ID:          "contoso",
+				ResourceURI: "/subscriptions/contoso",
+				PlanID:      "plan",
+				OfferType:   metering.OfferTypeManagedApplication,
+				RateLimit:   tenant.RateLimitConfiguration{RequestsPerSecond: 1, Burst: 1},
+			},
+			{
+				ID:          "fabrikam",
+				ResourceURI: "subscription-id",
+				PlanID:      "plan",
+				OfferType:   metering.OfferTypeSaaS,
+			},
+		},
+	}
+
+	sinkConfiguration := metering.SinkConfiguration{
+		QueueSize:       10,
+		MaxAttempts:     1,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+	}
+
+	registry, err := tenant.NewRegistry(
+		logger, configuration, metering.Configuration{}, sinkConfiguration, fakeCredential{}, nil, nil)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	// events without quantity are skipped, so they never reach the marketplace
+	event := coreMetering.UsageEvent{DimensionID: "gpu", Quantity: 0, StartAt: time.Now()}
+
+	send := func(tenantID string) error {
+		ctx, cancel := context.WithTimeout(metering.WithTenant(context.Background(), tenantID), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := registry.CreateUsageEvent(ctx, event)
+		return err
+	}
+
+	if err := send("contoso"); err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	if err := send("contoso"); !errors.Is(err, metering.ErrRateLimited) {
+		t.Fatalf("should be rate limited, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := send("fabrikam"); err != nil {
+			t.Fatalf("tenants without rate limit should not be limited, got %v", err)
+		}
+	}
+
+	for _, tenantID := range []string{"", "unknown"} {
+		if err := send(tenantID); !errors.Is(err, metering.ErrUnknownTenant) {
+			t.Fatalf("tenant '%s' should be unknown, got %v", tenantID, err)
+		}
+	}
+
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatalf("should close without error, got %v", err)
+	}
+}
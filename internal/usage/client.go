@@ -3,13 +3,26 @@ package usage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
 )
 
+// Azure Resource Manager token scope and endpoint, which readiness checks use to verify the quota API works
+const (
+	TokenScope = "https://management.azure.com/.default"
+	Endpoint   = "https://management.azure.com"
+)
+
+// ErrUsageNotFound is returned when the location has no usage with the name
+var ErrUsageNotFound = errors.New("usage not found")
+
 // Client defines an interface for usage client
 type Client interface {
 	ComputeUsage(context.Context, string, string) (compute.Usage, error)
+	ListComputeUsages(context.Context, string) ([]compute.Usage, error)
 }
 
 type usageClient struct {
@@ -23,12 +36,10 @@ func NewClient(client *compute.UsageClient) Client {
 	}
 }
 
-// ComputeUsage fetches compute usage list and filter a machine type
+// ComputeUsage fetches compute usage list and filter a machine type, the names are compared without case
 func (c usageClient) ComputeUsage(ctx context.Context, location string, machineType string) (compute.Usage, error) {
 	pager := c.client.NewListPager(location, nil)
 
-	var gpuUsage compute.Usage
-
 	for pager.More() {
 		nextResult, err := pager.NextPage(ctx)
 		if err != nil {
@@ -36,12 +47,32 @@ func (c usageClient) ComputeUsage(ctx context.Context, location string, machineT
 		}
 
 		for _, usage := range nextResult.Value {
-			if *usage.Name.Value == machineType {
-				gpuUsage = *usage
-				break
+			if usage.Name != nil && usage.Name.Value != nil && strings.EqualFold(*usage.Name.Value, machineType) {
+				return *usage, nil
+			}
+		}
+	}
+
+	return compute.Usage{}, fmt.Errorf("%w: %s in %s", ErrUsageNotFound, machineType, location)
+}
+
+// ListComputeUsages fetches every page of the compute usage list of a location
+func (c usageClient) ListComputeUsages(ctx context.Context, location string) ([]compute.Usage, error) {
+	pager := c.client.NewListPager(location, nil)
+
+	usages := []compute.Usage{}
+	for pager.More() {
+		nextResult, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, usage := range nextResult.Value {
+			if usage != nil {
+				usages = append(usages, *usage)
 			}
 		}
 	}
 
-	return gpuUsage, nil
+	return usages, nil
 }
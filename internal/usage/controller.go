@@ -3,19 +3,27 @@ package usage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/ydataai/go-core/pkg/common/config"
 	"github.com/ydataai/go-core/pkg/common/server"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/openapi"
 )
 
 // RESTController defines rest controller
 type RESTController struct {
 	logger        logging.Logger
 	restService   RESTService
+	guard         auth.Guard
 	configuration config.RESTControllerConfiguration
 }
 
@@ -23,10 +31,12 @@ type RESTController struct {
 func NewRESTController(
 	logger logging.Logger,
 	restService RESTService,
+	guard auth.Guard,
 	configuration config.RESTControllerConfiguration,
 ) RESTController {
 	return RESTController{
 		restService:   restService,
+		guard:         guard,
 		logger:        logger,
 		configuration: configuration,
 	}
@@ -34,7 +44,71 @@ func NewRESTController(
 
 // Boot ...
 func (r RESTController) Boot(s server.Server) {
-	s.Router().GET("/available/gpu", r.getAvailableGPU())
+	group := s.Router().Group("/available", r.guard.Require(auth.ScopeQuotaRead))
+	group.GET("/gpu", r.getAvailableGPU())
+
+	quota := s.Router().Group("/quota", r.guard.Require(auth.ScopeQuotaRead))
+	quota.GET("/:location", r.listQuotas())
+	quota.GET("/:location/:usageName", r.getQuota())
+}
+
+// Describe adds the operations of the controller to the OpenAPI specification
+func (r RESTController) Describe(spec *openapi.Spec) {
+	quota := spec.Schema(Quota{},
+		"location", "name", "localizedName", "unit", "limit", "current", "available", "percentage")
+
+	spec.AddOperation(http.MethodGet, "/available/gpu", openapi.Operation{
+		ID:      "availableGPU",
+		Summary: "Returns the number of GPUs that can still be allocated",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewQueryParameter("location").
+				WithDescription("Location of the quota, the configured one by default").
+				WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewQueryParameter("machineType").
+				WithDescription("Usage name of a GPU family with a known number of vCPUs per GPU, " +
+					"the configured one by default. The quota of the other families is at /quota").
+				WithSchema(openapi3.NewStringSchema())},
+		},
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: spec.Schema(GPU(0))},
+	})
+
+	spec.AddOperation(http.MethodGet, "/quota/:location", openapi.Operation{
+		ID: "listQuotas",
+		Summary: "Returns the limit, the current value and the available amount of the compute usages in a location " +
+			"that are the configured machine type or one of the usage names",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewPathParameter("location").
+				WithDescription("Location of the quotas, e.g. westeurope").
+				WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewQueryParameter("prefix").
+				WithDescription("Selects the usage names that start with the prefix, compared without case").
+				WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewQueryParameter("sort").
+				WithDescription("Field the usages are sorted by, the ties are sorted by name").
+				WithSchema(openapi3.NewStringSchema().
+					WithEnum(string(QuotaSortName), string(QuotaSortLimit), string(QuotaSortCurrent),
+						string(QuotaSortAvailable), string(QuotaSortPercentage)).
+					WithDefault(string(QuotaSortName)))},
+			{Value: openapi3.NewQueryParameter("order").
+				WithDescription("Order of the sort").
+				WithSchema(openapi3.NewStringSchema().WithEnum("asc", "desc").WithDefault("asc"))},
+		},
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: openapi3.NewArraySchema().WithItems(quota.Value).NewRef()},
+	})
+
+	spec.AddOperation(http.MethodGet, "/quota/:location/:usageName", openapi.Operation{
+		ID:      "getQuota",
+		Summary: "Returns the limit, the current value and the available amount of a compute usage in a location",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewPathParameter("location").
+				WithDescription("Location of the quota, e.g. westeurope").
+				WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewPathParameter("usageName").
+				WithDescription("Usage name, which must be the configured machine type or one of the usage names").
+				WithSchema(openapi3.NewStringSchema())},
+		},
+		Responses: map[int]*openapi3.SchemaRef{http.StatusOK: quota},
+	})
 }
 
 func (r RESTController) getAvailableGPU() gin.HandlerFunc {
@@ -42,13 +116,76 @@ func (r RESTController) getAvailableGPU() gin.HandlerFunc {
 		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
 		defer cancel()
 
-		gpu, err := r.restService.AvailableGPU(tCtx)
+		gpu, err := r.restService.AvailableGPUOf(tCtx, ctx.Query("location"), ctx.Query("machineType"))
 		if err != nil {
-			r.logger.Errorf("while fetching available resources. Error: %s", err.Error())
-			ctx.JSON(http.StatusInternalServerError, err)
+			correlation.Logger(ctx, r.logger).Errorf("while fetching available resources. Error: %s", err.Error())
+			ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
 			return
 		}
 
 		ctx.JSON(http.StatusOK, gpu)
 	}
 }
+
+func (r RESTController) getQuota() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		quota, err := r.restService.Quota(tCtx, ctx.Param("location"), ctx.Param("usageName"))
+		if err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("while fetching the quota. Error: %s", err.Error())
+			ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, quota)
+	}
+}
+
+func (r RESTController) listQuotas() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter := QuotaFilter{
+			Prefix: ctx.Query("prefix"),
+			Sort:   QuotaSort(ctx.DefaultQuery("sort", string(QuotaSortName))),
+		}
+		if err := filter.Sort.validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		switch order := ctx.DefaultQuery("order", "asc"); order {
+		case "asc":
+		case "desc":
+			filter.Descending = true
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid order '%s'", order)})
+			return
+		}
+
+		tCtx, cancel := context.WithTimeout(ctx, r.configuration.HTTPRequestTimeout)
+		defer cancel()
+
+		quotas, err := r.restService.Quotas(tCtx, ctx.Param("location"), filter)
+		if err != nil {
+			correlation.Logger(ctx, r.logger).Errorf("while fetching the quotas. Error: %s", err.Error())
+			ctx.JSON(errorStatusCode(err), gin.H{"message": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, quotas)
+	}
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownGPUFamily):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUsageNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrUsageNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
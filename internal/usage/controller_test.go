@@ -0,0 +1,134 @@
+package usage_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ydataai/go-core/pkg/common/config"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/common/server"
+
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/usage"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestRESTController(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	serve := func(restService usage.RESTService, path string) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		httpServer := server.NewServer(logger, server.HTTPServerConfiguration{})
+		configuration := config.RESTControllerConfiguration{HTTPRequestTimeout: time.Second}
+		usage.NewRESTController(logger, restService, auth.NewGuard(logger, nil), configuration).
+			Boot(httpServer)
+
+		recorder := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	t.Run("quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := usage.Quota{Location: "eastus", Name: "standardNCFamily", Unit: "Count", Limit: 24, Available: 24}
+		restService := mock.NewMockRESTServiceInterface(ctrl)
+		restService.EXPECT().Quota(gomock.Any(), "eastus", "standardNCFamily").Return(expected, nil)
+
+		recorder := serve(restService, "/quota/eastus/standardNCFamily")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d %s", recorder.Code, recorder.Body.String())
+		}
+
+		quota := usage.Quota{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &quota); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, quota); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("available gpu of the query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		restService := mock.NewMockRESTServiceInterface(ctrl)
+		restService.EXPECT().AvailableGPUOf(gomock.Any(), "eastus", "standardNCFamily").Return(usage.GPU(2), nil)
+
+		recorder := serve(restService, "/available/gpu?location=eastus&machineType=standardNCFamily")
+		if recorder.Code != http.StatusOK || recorder.Body.String() != "2" {
+			t.Fatalf("expected 2 GPUs, got %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("quotas", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		filter := usage.QuotaFilter{Prefix: "standard", Sort: usage.QuotaSortAvailable, Descending: true}
+		restService := mock.NewMockRESTServiceInterface(ctrl)
+		restService.EXPECT().Quotas(gomock.Any(), "eastus", filter).Return([]usage.Quota{}, nil)
+
+		recorder := serve(restService, "/quota/eastus?prefix=standard&sort=available&order=desc")
+		if recorder.Code != http.StatusOK || recorder.Body.String() != "[]" {
+			t.Fatalf("expected no quotas, got %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("invalid quotas filter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		restService := mock.NewMockRESTServiceInterface(ctrl)
+
+		for _, query := range []string{"sort=size", "order=up"} {
+			if recorder := serve(restService, "/quota/eastus?"+query); recorder.Code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status 400, got %d", query, recorder.Code)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tt := []struct {
+			name   string
+			err    error
+			status int
+		}{
+			{name: "unknown gpu family", err: usage.ErrUnknownGPUFamily, status: http.StatusBadRequest},
+			{name: "not allowed", err: usage.ErrUsageNotAllowed, status: http.StatusForbidden},
+			{name: "not found", err: usage.ErrUsageNotFound, status: http.StatusNotFound},
+			{name: "azure", err: errors.New("azure error"), status: http.StatusInternalServerError},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				restService := mock.NewMockRESTServiceInterface(ctrl)
+				restService.EXPECT().Quota(gomock.Any(), "eastus", "cores").Return(usage.Quota{}, tc.err)
+
+				if recorder := serve(restService, "/quota/eastus/cores"); recorder.Code != tc.status {
+					t.Fatalf("expected status %d, got %d", tc.status, recorder.Code)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,69 @@
+// Package usage offers objects and methods to help using usage APIs
+package usage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	adapterv1 "github.com/ydataai/azure-adapter/api/adapter/v1"
+	"github.com/ydataai/azure-adapter/internal/auth"
+	"github.com/ydataai/azure-adapter/internal/correlation"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+)
+
+// GRPCController defines the gRPC controller of the quota service
+type GRPCController struct {
+	adapterv1.UnimplementedQuotaServiceServer
+
+	logger        logging.Logger
+	restService   RESTService
+	configuration rpc.ServerConfiguration
+}
+
+// NewGRPCController initializes the gRPC controller
+func NewGRPCController(
+	logger logging.Logger,
+	restService RESTService,
+	configuration rpc.ServerConfiguration,
+) GRPCController {
+	return GRPCController{
+		logger:        logger,
+		restService:   restService,
+		configuration: configuration,
+	}
+}
+
+// Boot registers the quota service
+func (g GRPCController) Boot(s rpc.Server) {
+	adapterv1.RegisterQuotaServiceServer(s.Registrar(), g)
+}
+
+// Scopes returns the scopes each method requires, the same as the REST routes
+func (g GRPCController) Scopes() map[string][]string {
+	return map[string][]string{
+		adapterv1.QuotaService_AvailableGPU_FullMethodName: {auth.ScopeQuotaRead},
+	}
+}
+
+// AvailableGPU returns the number of GPUs that can still be allocated
+func (g GRPCController) AvailableGPU(
+	ctx context.Context, _ *adapterv1.AvailableGPURequest,
+) (*adapterv1.AvailableGPUResponse, error) {
+	tCtx, cancel := context.WithTimeout(ctx, g.configuration.RequestTimeout)
+	defer cancel()
+
+	gpu, err := g.restService.AvailableGPU(tCtx)
+	if err != nil {
+		correlation.Logger(ctx, g.logger).Errorf("while fetching available resources. Error: %s", err.Error())
+		if errors.Is(err, ErrUsageNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &adapterv1.AvailableGPUResponse{Gpu: int64(gpu)}, nil
+}
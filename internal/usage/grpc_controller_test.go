@@ -0,0 +1,84 @@
+package usage_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	adapterv1 "github.com/ydataai/azure-adapter/api/adapter/v1"
+	"github.com/ydataai/azure-adapter/internal/rpc"
+	"github.com/ydataai/azure-adapter/internal/usage"
+	"github.com/ydataai/azure-adapter/mock"
+)
+
+func TestGRPCAvailableGPU(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	restService := mock.NewMockRESTServiceInterface(ctrl)
+	restService.EXPECT().AvailableGPU(gomock.Any()).Return(usage.GPU(3), nil)
+
+	configuration := rpc.ServerConfiguration{RequestTimeout: time.Second}
+	grpcServer := grpc.NewServer()
+	usage.NewGRPCController(logger, restService, configuration).Boot(registrar{grpcServer})
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+	defer conn.Close()
+
+	response, err := adapterv1.NewQuotaServiceClient(conn).AvailableGPU(context.Background(), &adapterv1.AvailableGPURequest{})
+	if err != nil {
+		t.Fatalf("should not return any error, got %v", err)
+	}
+
+	if response.GetGpu() != 3 {
+		t.Fatalf("should be 3, got %v", response.GetGpu())
+	}
+}
+
+// registrar exposes a plain grpc.Server as an rpc.Server for the controllers to register on
+type registrar struct {
+	server *grpc.Server
+}
+
+func (r registrar) Registrar() grpc.ServiceRegistrar {
+	return r.server
+}
+
+func (r registrar) Configuration() rpc.ServerConfiguration {
+	return rpc.ServerConfiguration{}
+}
+
+func (r registrar) Run(context.Context) error {
+	return nil
+}
+
+func (r registrar) Shutdown(context.Context) error {
+	return nil
+}
@@ -1,5 +1,125 @@
 // Package usage offers objects and methods to help using usage APIs
 package usage
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+)
+
 // GPU defines a type to represent number of gpu instances available
 type GPU int64
+
+// Quota represents the usage of a compute quota in a location, e.g. the vCPUs of a machine family
+type Quota struct {
+	Location      string `json:"location"`
+	Name          string `json:"name"`
+	LocalizedName string `json:"localizedName"`
+	Unit          string `json:"unit"`
+	Limit         int64  `json:"limit"`
+	Current       int64  `json:"current"`
+	Available     int64  `json:"available"`
+	// Percentage is the share of the limit in use, rounded to two decimals, which is 0 when there's no limit
+	Percentage float64 `json:"percentage"`
+}
+
+// QuotaSort defines the field the quotas of a location are sorted by, the ties are sorted by ascending name
+type QuotaSort string
+
+// Supported sort fields
+const (
+	QuotaSortName       QuotaSort = "name"
+	QuotaSortLimit      QuotaSort = "limit"
+	QuotaSortCurrent    QuotaSort = "current"
+	QuotaSortAvailable  QuotaSort = "available"
+	QuotaSortPercentage QuotaSort = "percentage"
+)
+
+// QuotaFilter selects and sorts the quotas of a location
+type QuotaFilter struct {
+	// Prefix selects the usage names that start with it, compared without case
+	Prefix     string
+	Sort       QuotaSort
+	Descending bool
+}
+
+// newQuota converts the usage azure returned, the available amount is never negative
+func newQuota(location string, usage compute.Usage) Quota {
+	quota := Quota{Location: location}
+	if usage.Name != nil && usage.Name.Value != nil {
+		quota.Name = *usage.Name.Value
+	}
+	if usage.Name != nil && usage.Name.LocalizedValue != nil {
+		quota.LocalizedName = *usage.Name.LocalizedValue
+	}
+	if usage.Unit != nil {
+		quota.Unit = *usage.Unit
+	}
+	if usage.Limit != nil {
+		quota.Limit = *usage.Limit
+	}
+	if usage.CurrentValue != nil {
+		quota.Current = int64(*usage.CurrentValue)
+	}
+	quota.Available = max(quota.Limit-quota.Current, 0)
+	if quota.Limit > 0 {
+		quota.Percentage = math.Round(float64(quota.Current)/float64(quota.Limit)*10000) / 100
+	}
+
+	return quota
+}
+
+func (s QuotaSort) validate() error {
+	switch s {
+	case QuotaSortName, QuotaSortLimit, QuotaSortCurrent, QuotaSortAvailable, QuotaSortPercentage:
+		return nil
+	default:
+		return fmt.Errorf("invalid quota sort '%s'", s)
+	}
+}
+
+// apply returns the quotas selected by the filter in its order, the name is the default sort
+func (f QuotaFilter) apply(quotas []Quota) []Quota {
+	prefix := strings.ToLower(f.Prefix)
+
+	selected := []Quota{}
+	for _, quota := range quotas {
+		if strings.HasPrefix(strings.ToLower(quota.Name), prefix) {
+			selected = append(selected, quota)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if f.Descending {
+			a, b = b, a
+		}
+
+		switch f.Sort {
+		case QuotaSortLimit:
+			if a.Limit != b.Limit {
+				return a.Limit < b.Limit
+			}
+		case QuotaSortCurrent:
+			if a.Current != b.Current {
+				return a.Current < b.Current
+			}
+		case QuotaSortAvailable:
+			if a.Available != b.Available {
+				return a.Available < b.Available
+			}
+		case QuotaSortPercentage:
+			if a.Percentage != b.Percentage {
+				return a.Percentage < b.Percentage
+			}
+		default:
+			return a.Name < b.Name
+		}
+		return selected[i].Name < selected[j].Name
+	})
+
+	return selected
+}
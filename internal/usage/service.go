@@ -3,23 +3,56 @@ package usage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/ydataai/go-core/pkg/common/logging"
+
+	"github.com/ydataai/azure-adapter/internal/correlation"
 )
 
 const (
 	vCPUToGPUFactor int64 = 6
 )
 
+// gpuFactors are the vCPUs per GPU of the GPU families whose sizes all have the same ratio
+var gpuFactors = map[string]int64{
+	"standardNCFamily":          6,
+	"standardNCSv2Family":       6,
+	"standardNCSv3Family":       6,
+	"standardNDSFamily":         6,
+	"standardNVFamily":          6,
+	"standardNVSv3Family":       12,
+	"standardNCASv3_T4Family":   4,
+	"standardNCADSA100v4Family": 24,
+}
+
+// ErrUsageNotAllowed is returned when the usage name is neither the machine type nor one of the usage names
+var ErrUsageNotAllowed = errors.New("usage name is not allowed")
+
+// ErrUnknownGPUFamily is returned when the GPUs of a machine type are requested and its vCPUs per GPU aren't known
+var ErrUnknownGPUFamily = errors.New("machine type isn't a known GPU family")
+
 // RESTServiceInterface defines rest service interface
 type RESTService interface {
 	AvailableGPU(ctx context.Context) (GPU, error)
+	// AvailableGPUOf returns the GPUs of a known GPU family in a location, the empty ones are the configured ones
+	AvailableGPUOf(ctx context.Context, location string, machineType string) (GPU, error)
+	// Quota returns the quota of an allowed usage name in a location, the empty ones are the configured ones
+	Quota(ctx context.Context, location string, usageName string) (Quota, error)
+	// Quotas returns the allowed quotas of a location selected by the filter, the empty location is the configured one
+	Quotas(ctx context.Context, location string, filter QuotaFilter) ([]Quota, error)
+	// Reload applies the machine type and the usage names of the configuration, the location requires a restart
+	Reload(configuration RESTServiceConfiguration)
 }
 
 // RESTService defines a struct with required dependencies for rest service
 type restService struct {
 	logger        logging.Logger
-	configuration RESTServiceConfiguration
+	mu            *sync.RWMutex
+	configuration *RESTServiceConfiguration
 	usageClient   Client
 }
 
@@ -31,29 +64,135 @@ func NewRESTService(
 ) RESTService {
 	return restService{
 		logger:        logger,
-		configuration: configuration,
+		mu:            &sync.RWMutex{},
+		configuration: &configuration,
 		usageClient:   usageClient,
 	}
 }
 
 // AvailableGPU ..
 func (rs restService) AvailableGPU(ctx context.Context) (GPU, error) {
-	rs.logger.Infof("fetch available GPUs from quota API")
+	return rs.AvailableGPUOf(ctx, "", "")
+}
 
-	usageResult, err := rs.usageClient.ComputeUsage(ctx, rs.configuration.Location, rs.configuration.MachineType)
+// AvailableGPUOf returns the GPUs available of a known GPU family in a location, the empty ones are the configured ones.
+// The configured machine type keeps the default vCPUs per GPU when its family isn't known.
+func (rs restService) AvailableGPUOf(ctx context.Context, location string, machineType string) (GPU, error) {
+	logger := correlation.Logger(ctx, rs.logger)
+	logger.Infof("fetch available GPUs from quota API")
+
+	factor := vCPUToGPUFactor
+	if machineType == "" {
+		if known, ok := gpuFactor(rs.currentConfiguration().MachineType); ok {
+			factor = known
+		}
+	} else {
+		known, ok := gpuFactor(machineType)
+		if !ok {
+			return GPU(0), fmt.Errorf("%w: %s, its quota is available at /quota", ErrUnknownGPUFamily, machineType)
+		}
+		factor = known
+	}
+
+	quota, err := rs.Quota(ctx, location, machineType)
 	if err != nil {
-		rs.logger.Errorf(
-			"while fetching list of %s/%s with error %v", rs.configuration.Location, rs.configuration.MachineType, err)
 		return GPU(0), err
 	}
 
-	rs.logger.Infof(
-		"resources for %s/%s -> Limit: %d | Current: %d",
-		rs.configuration.Location, rs.configuration.MachineType, *usageResult.Limit, *usageResult.CurrentValue)
+	availableGPU := quota.Available / factor
 
-	availableGPU := (*usageResult.Limit - int64(*usageResult.CurrentValue)) / vCPUToGPUFactor
-
-	rs.logger.Infof("Number of GPUs available in %s: %d", rs.configuration.Location, availableGPU)
+	logger.Infof("Number of GPUs available in %s: %d", quota.Location, availableGPU)
 
 	return GPU(availableGPU), nil
 }
+
+// Quota returns the quota of a usage name in a location, the empty ones are the configured ones
+func (rs restService) Quota(ctx context.Context, location string, usageName string) (Quota, error) {
+	logger := correlation.Logger(ctx, rs.logger)
+
+	configuration := rs.currentConfiguration()
+	if location == "" {
+		location = configuration.Location
+	}
+	if usageName == "" {
+		usageName = configuration.MachineType
+	}
+
+	if !configuration.Allows(usageName) {
+		return Quota{}, fmt.Errorf("%w: %s", ErrUsageNotAllowed, usageName)
+	}
+
+	usageResult, err := rs.usageClient.ComputeUsage(ctx, location, usageName)
+	if err != nil {
+		logger.Errorf("while fetching list of %s/%s with error %v", location, usageName, err)
+		return Quota{}, err
+	}
+
+	quota := newQuota(location, usageResult)
+
+	logger.Infof("resources for %s/%s -> Limit: %d | Current: %d", location, usageName, quota.Limit, quota.Current)
+
+	return quota, nil
+}
+
+// Quotas returns the quotas of the machine type and the usage names in a location selected by the filter
+func (rs restService) Quotas(ctx context.Context, location string, filter QuotaFilter) ([]Quota, error) {
+	logger := correlation.Logger(ctx, rs.logger)
+
+	configuration := rs.currentConfiguration()
+	if location == "" {
+		location = configuration.Location
+	}
+
+	usages, err := rs.usageClient.ListComputeUsages(ctx, location)
+	if err != nil {
+		logger.Errorf("while fetching list of %s with error %v", location, err)
+		return nil, err
+	}
+
+	quotas := make([]Quota, 0, len(usages))
+	for _, usage := range usages {
+		if usage.Name == nil || usage.Name.Value == nil || !configuration.Allows(*usage.Name.Value) {
+			continue
+		}
+		quotas = append(quotas, newQuota(location, usage))
+	}
+
+	logger.Infof("fetched %d allowed usages of %d in %s", len(quotas), len(usages), location)
+
+	return filter.apply(quotas), nil
+}
+
+// Reload applies the machine type and the usage names, the requests in-flight keep the previous one
+func (rs restService) Reload(configuration RESTServiceConfiguration) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if configuration.Location != rs.configuration.Location {
+		rs.logger.Warnf("location changed to %s, it requires a restart", configuration.Location)
+	}
+
+	if configuration.MachineType != rs.configuration.MachineType {
+		rs.logger.Infof("machine type changed from %s to %s", rs.configuration.MachineType, configuration.MachineType)
+		rs.configuration.MachineType = configuration.MachineType
+	}
+
+	rs.configuration.UsageNames = configuration.UsageNames
+}
+
+func (rs restService) currentConfiguration() RESTServiceConfiguration {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return *rs.configuration
+}
+
+// gpuFactor returns the vCPUs per GPU of a GPU family, the names are compared without case
+func gpuFactor(machineType string) (int64, bool) {
+	for family, factor := range gpuFactors {
+		if strings.EqualFold(family, machineType) {
+			return factor, true
+		}
+	}
+	return 0, false
+}
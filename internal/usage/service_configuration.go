@@ -2,6 +2,8 @@
 package usage
 
 import (
+	"strings"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -9,9 +11,24 @@ import (
 type RESTServiceConfiguration struct {
 	Location    string `envconfig:"LOCATION" required:"true"`
 	MachineType string `envconfig:"MACHINE_TYPE" required:"true"`
+	// UsageNames are the usage names that can be requested besides the machine type, e.g. standardNCASv3_T4Family
+	UsageNames []string `envconfig:"QUOTA_USAGE_NAMES" default:""`
 }
 
 // LoadFromEnvVars parses the required configuration variables
 func (c *RESTServiceConfiguration) LoadFromEnvVars() error {
 	return envconfig.Process("", c)
 }
+
+// Allows returns if the usage name can be requested, the names are compared without case
+func (c RESTServiceConfiguration) Allows(usageName string) bool {
+	if strings.EqualFold(usageName, c.MachineType) {
+		return true
+	}
+	for _, name := range c.UsageNames {
+		if strings.EqualFold(usageName, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
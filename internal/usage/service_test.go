@@ -107,3 +107,230 @@ func TestAvailableGPU(t *testing.T) {
 		}
 	})
 }
+
+func TestQuota(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	restServiceConfiguration := usage.RESTServiceConfiguration{
+		Location:    "westeurope",
+		MachineType: "standardNCFamily",
+		UsageNames:  []string{"standardNCASv3_T4Family"},
+	}
+
+	t.Run("requested usage name", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "standardNCASv3_T4Family"
+		unit := "Count"
+		currentValue := int32(16)
+		limit := int64(12)
+		usageClient := mock.NewMockUsageClientInterface(ctrl)
+		usageClient.EXPECT().
+			ComputeUsage(gomock.Any(), "eastus", "standardncasv3_t4family").
+			Return(compute.Usage{
+				Name:         &compute.UsageName{Value: &name},
+				Unit:         &unit,
+				CurrentValue: &currentValue,
+				Limit:        &limit,
+			}, nil)
+
+		restService := usage.NewRESTService(logger, restServiceConfiguration, usageClient)
+
+		quota, err := restService.Quota(context.Background(), "eastus", "standardncasv3_t4family")
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		expected := usage.Quota{
+			Location: "eastus", Name: name, Unit: "Count", Limit: 12, Current: 16, Available: 0, Percentage: 133.33,
+		}
+		if diff := cmp.Diff(expected, quota); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("configured usage name", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		currentValue := int32(0)
+		limit := int64(24)
+		usageClient := mock.NewMockUsageClientInterface(ctrl)
+		usageClient.EXPECT().
+			ComputeUsage(gomock.Any(), "westeurope", "standardNCFamily").
+			Return(compute.Usage{CurrentValue: &currentValue, Limit: &limit}, nil)
+
+		restService := usage.NewRESTService(logger, restServiceConfiguration, usageClient)
+
+		gpu, err := restService.AvailableGPUOf(context.Background(), "", "")
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		if gpu != usage.GPU(4) {
+			t.Fatalf("should be 4, got %v", gpu)
+		}
+	})
+
+	t.Run("available gpu of a known family", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		currentValue := int32(4)
+		limit := int64(16)
+		usageClient := mock.NewMockUsageClientInterface(ctrl)
+		usageClient.EXPECT().
+			ComputeUsage(gomock.Any(), "westeurope", "standardNCASv3_T4Family").
+			Return(compute.Usage{CurrentValue: &currentValue, Limit: &limit}, nil)
+
+		restService := usage.NewRESTService(logger, restServiceConfiguration, usageClient)
+
+		gpu, err := restService.AvailableGPUOf(context.Background(), "", "standardNCASv3_T4Family")
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		if gpu != usage.GPU(3) {
+			t.Fatalf("should be 3, got %v", gpu)
+		}
+	})
+
+	t.Run("available gpu of an unknown family", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		configuration := restServiceConfiguration
+		configuration.UsageNames = []string{"standardDSv3Family"}
+		restService := usage.NewRESTService(logger, configuration, mock.NewMockUsageClientInterface(ctrl))
+
+		_, err := restService.AvailableGPUOf(context.Background(), "", "standardDSv3Family")
+		if !errors.Is(err, usage.ErrUnknownGPUFamily) {
+			t.Fatalf("expected the gpu family to be unknown, got %v", err)
+		}
+	})
+
+	t.Run("usage name not allowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		restService := usage.NewRESTService(logger, restServiceConfiguration, mock.NewMockUsageClientInterface(ctrl))
+
+		if _, err := restService.Quota(context.Background(), "westeurope", "cores"); !errors.Is(err, usage.ErrUsageNotAllowed) {
+			t.Fatalf("expected the usage name not to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestQuotas(t *testing.T) {
+	loggerConfiguration := logging.LoggerConfiguration{}
+	if err := loggerConfiguration.LoadFromEnvVars(); err != nil {
+		fmt.Println(fmt.Errorf("could not set logging configuration. Err: %v", err))
+		os.Exit(1)
+	}
+
+	logger := logging.NewLogger(loggerConfiguration)
+
+	newUsage := func(name string, localizedName string, current int32, limit int64) compute.Usage {
+		unit := "Count"
+		return compute.Usage{
+			Name:         &compute.UsageName{Value: &name, LocalizedValue: &localizedName},
+			Unit:         &unit,
+			CurrentValue: &current,
+			Limit:        &limit,
+		}
+	}
+	usages := []compute.Usage{
+		newUsage("standardNCFamily", "Standard NC Family vCPUs", 6, 24),
+		newUsage("cores", "Total Regional vCPUs", 25, 100),
+		newUsage("standardNCASv3_T4Family", "Standard NCASv3_T4 Family vCPUs", 8, 8),
+		newUsage("standardNDFamily", "Standard ND Family vCPUs", 0, 0),
+		newUsage("standardDSv3Family", "Standard DSv3 Family vCPUs", 0, 10),
+	}
+	restServiceConfiguration := usage.RESTServiceConfiguration{
+		Location:    "westeurope",
+		MachineType: "standardNCFamily",
+		UsageNames:  []string{"cores", "standardNCASv3_T4Family", "standardNDFamily"},
+	}
+
+	tt := []struct {
+		name     string
+		filter   usage.QuotaFilter
+		expected []string
+	}{
+		{
+			name:     "allowed by name",
+			filter:   usage.QuotaFilter{},
+			expected: []string{"cores", "standardNCASv3_T4Family", "standardNCFamily", "standardNDFamily"},
+		},
+		{
+			name:     "prefix",
+			filter:   usage.QuotaFilter{Prefix: "STANDARDNC"},
+			expected: []string{"standardNCASv3_T4Family", "standardNCFamily"},
+		},
+		{
+			name:     "available descending",
+			filter:   usage.QuotaFilter{Sort: usage.QuotaSortAvailable, Descending: true},
+			expected: []string{"cores", "standardNCFamily", "standardNCASv3_T4Family", "standardNDFamily"},
+		},
+		{
+			name:     "percentage with ties by name",
+			filter:   usage.QuotaFilter{Sort: usage.QuotaSortPercentage},
+			expected: []string{"standardNDFamily", "cores", "standardNCFamily", "standardNCASv3_T4Family"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			usageClient := mock.NewMockUsageClientInterface(ctrl)
+			usageClient.EXPECT().ListComputeUsages(gomock.Any(), "westeurope").Return(usages, nil)
+
+			restService := usage.NewRESTService(logger, restServiceConfiguration, usageClient)
+
+			quotas, err := restService.Quotas(context.Background(), "", tc.filter)
+			if err != nil {
+				t.Fatalf("should not return any error, got %v", err)
+			}
+
+			names := []string{}
+			for _, quota := range quotas {
+				names = append(names, quota.Name)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Fatalf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("usage fields", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		usageClient := mock.NewMockUsageClientInterface(ctrl)
+		usageClient.EXPECT().ListComputeUsages(gomock.Any(), "eastus").Return(usages[:1], nil)
+
+		restService := usage.NewRESTService(logger, restServiceConfiguration, usageClient)
+
+		quotas, err := restService.Quotas(context.Background(), "eastus", usage.QuotaFilter{})
+		if err != nil {
+			t.Fatalf("should not return any error, got %v", err)
+		}
+
+		expected := []usage.Quota{{
+			Location: "eastus", Name: "standardNCFamily", LocalizedName: "Standard NC Family vCPUs", Unit: "Count",
+			Limit: 24, Current: 6, Available: 18, Percentage: 25,
+		}}
+		if diff := cmp.Diff(expected, quotas); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -49,3 +49,60 @@ func (mr *MockRESTServiceInterfaceMockRecorder) AvailableGPU(ctx interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailableGPU", reflect.TypeOf((*MockRESTServiceInterface)(nil).AvailableGPU), ctx)
 }
+
+// AvailableGPUOf mocks base method.
+func (m *MockRESTServiceInterface) AvailableGPUOf(ctx context.Context, location, machineType string) (usage.GPU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvailableGPUOf", ctx, location, machineType)
+	ret0, _ := ret[0].(usage.GPU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AvailableGPUOf indicates an expected call of AvailableGPUOf.
+func (mr *MockRESTServiceInterfaceMockRecorder) AvailableGPUOf(ctx, location, machineType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailableGPUOf", reflect.TypeOf((*MockRESTServiceInterface)(nil).AvailableGPUOf), ctx, location, machineType)
+}
+
+// Quota mocks base method.
+func (m *MockRESTServiceInterface) Quota(ctx context.Context, location, usageName string) (usage.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quota", ctx, location, usageName)
+	ret0, _ := ret[0].(usage.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quota indicates an expected call of Quota.
+func (mr *MockRESTServiceInterfaceMockRecorder) Quota(ctx, location, usageName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quota", reflect.TypeOf((*MockRESTServiceInterface)(nil).Quota), ctx, location, usageName)
+}
+
+// Quotas mocks base method.
+func (m *MockRESTServiceInterface) Quotas(ctx context.Context, location string, filter usage.QuotaFilter) ([]usage.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quotas", ctx, location, filter)
+	ret0, _ := ret[0].([]usage.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quotas indicates an expected call of Quotas.
+func (mr *MockRESTServiceInterfaceMockRecorder) Quotas(ctx, location, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quotas", reflect.TypeOf((*MockRESTServiceInterface)(nil).Quotas), ctx, location, filter)
+}
+
+// Reload mocks base method.
+func (m *MockRESTServiceInterface) Reload(configuration usage.RESTServiceConfiguration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Reload", configuration)
+}
+
+// Reload indicates an expected call of Reload.
+func (mr *MockRESTServiceInterfaceMockRecorder) Reload(configuration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reload", reflect.TypeOf((*MockRESTServiceInterface)(nil).Reload), configuration)
+}
@@ -49,3 +49,18 @@ func (mr *MockUsageClientInterfaceMockRecorder) ComputeUsage(arg0, arg1, arg2 in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComputeUsage", reflect.TypeOf((*MockUsageClientInterface)(nil).ComputeUsage), arg0, arg1, arg2)
 }
+
+// ListComputeUsages mocks base method.
+func (m *MockUsageClientInterface) ListComputeUsages(arg0 context.Context, arg1 string) ([]compute.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListComputeUsages", arg0, arg1)
+	ret0, _ := ret[0].([]compute.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListComputeUsages indicates an expected call of ListComputeUsages.
+func (mr *MockUsageClientInterfaceMockRecorder) ListComputeUsages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListComputeUsages", reflect.TypeOf((*MockUsageClientInterface)(nil).ListComputeUsages), arg0, arg1)
+}